@@ -0,0 +1,190 @@
+package achievements
+
+import (
+	"unicode/utf8"
+
+	"github.com/ghousemohamed/regex-in-the-terminal/mastery"
+	"github.com/ghousemohamed/regex-in-the-terminal/models"
+	"github.com/ghousemohamed/regex-in-the-terminal/stats"
+)
+
+// ParGoal is how many practice problems must be solved at or under par for
+// the golf badge.
+const ParGoal = 10
+
+// StreakGoal is the length in days of the streak badge.
+const StreakGoal = 7
+
+// Badge is an achievement, earned once its rule holds and kept until a
+// reset undoes what earned it.
+type Badge struct {
+	ID          string
+	Icon        string
+	Name        string
+	Description string
+	earned      func(State) bool
+}
+
+// State is what badges are judged on: the course with its completion
+// flags, the statistics worked out from the attempt history, and the
+// learner's shortest solutions.
+type State struct {
+	Lessons   []models.Lesson
+	Practices []models.PracticeProblem
+	Summary   stats.Summary
+	Solved    map[string]string
+}
+
+// Badges lists every badge in the order the trophy screen shows them.
+var Badges = []Badge{
+	{
+		ID: "first-steps", Icon: "👣", Name: "First Steps",
+		Description: "Complete your first lesson",
+		earned: func(s State) bool {
+			for _, l := range s.Lessons {
+				if l.Completed {
+					return true
+				}
+			}
+			return false
+		},
+	},
+	{
+		ID: "first-try", Icon: "🎯", Name: "First Try",
+		Description: "Solve an exercise with your first pattern",
+		earned: func(s State) bool {
+			for _, e := range s.Summary.Exercises {
+				if e.FirstTry {
+					return true
+				}
+			}
+			return false
+		},
+	},
+	{
+		ID: "anchors-no-hints", Icon: "⚓", Name: "Anchored",
+		Description: "Complete every anchors lesson without a hint",
+		earned:      anchoredWithoutHints,
+	},
+	{
+		ID: "heavy-lifter", Icon: "💪", Name: "Heavy Lifter",
+		Description: "Solve a hard practice problem",
+		earned: func(s State) bool {
+			for _, p := range s.Practices {
+				if p.Completed && p.Difficulty == models.Hard {
+					return true
+				}
+			}
+			return false
+		},
+	},
+	{
+		ID: "golf-par", Icon: "⛳", Name: "Par for the Course",
+		Description: "Solve 10 practice problems at or under par",
+		earned: func(s State) bool {
+			return ParCount(s) >= ParGoal
+		},
+	},
+	{
+		ID: "week-streak", Icon: "🔥", Name: "On Fire",
+		Description: "Practise 7 days in a row",
+		earned: func(s State) bool {
+			return s.Summary.LongestStreak >= StreakGoal
+		},
+	},
+	{
+		ID: "graduate", Icon: "🎓", Name: "Graduate",
+		Description: "Complete every lesson",
+		earned: func(s State) bool {
+			for _, l := range s.Lessons {
+				if !l.Completed {
+					return false
+				}
+			}
+			return len(s.Lessons) > 0
+		},
+	},
+	{
+		ID: "problem-solver", Icon: "🧩", Name: "Problem Solver",
+		Description: "Solve every practice problem",
+		earned: func(s State) bool {
+			for _, p := range s.Practices {
+				if !p.Completed {
+					return false
+				}
+			}
+			return len(s.Practices) > 0
+		},
+	},
+}
+
+// anchoredWithoutHints reports whether every lesson whose solution uses
+// anchors is completed and none of them needed a hint.
+func anchoredWithoutHints(s State) bool {
+	hints := map[string]int{}
+	for _, e := range s.Summary.Exercises {
+		hints[e.ID] = e.HintsUsed
+	}
+
+	concepts := mastery.ExerciseConcepts(s.Lessons, nil)
+	found := false
+	for _, l := range s.Lessons {
+		for _, c := range concepts[l.ExerciseID()] {
+			if c != models.ConceptAnchors {
+				continue
+			}
+			if !l.Completed || hints[l.ExerciseID()] > 0 {
+				return false
+			}
+			found = true
+		}
+	}
+	return found
+}
+
+// ParCount returns how many practice problems have been solved in no more
+// characters than their reference solution.
+func ParCount(s State) int {
+	n := 0
+	for _, p := range s.Practices {
+		best, ok := s.Solved[p.ExerciseID()]
+		if ok && p.Solution != "" && utf8.RuneCountInString(best) <= utf8.RuneCountInString(p.Solution) {
+			n++
+		}
+	}
+	return n
+}
+
+// Find returns the badge with the given ID.
+func Find(id string) (Badge, bool) {
+	for _, b := range Badges {
+		if b.ID == id {
+			return b, true
+		}
+	}
+	return Badge{}, false
+}
+
+// Check is called whenever an exercise is completed. It returns the badges
+// the learner has just earned, leaving out those in have.
+func Check(s State, have map[string]bool) []Badge {
+	var earned []Badge
+	for _, b := range Badges {
+		if !have[b.ID] && b.earned(s) {
+			earned = append(earned, b)
+		}
+	}
+	return earned
+}
+
+// Lost returns the badges in have that s no longer earns, as after a
+// reset.
+func Lost(s State, have map[string]bool) []Badge {
+	var lost []Badge
+	for _, b := range Badges {
+		if have[b.ID] && !b.earned(s) {
+			lost = append(lost, b)
+		}
+	}
+	return lost
+}
@@ -0,0 +1,106 @@
+package achievements
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ghousemohamed/regex-in-the-terminal/models"
+	"github.com/ghousemohamed/regex-in-the-terminal/stats"
+)
+
+// anchorLessons are two completed lessons, one of them about anchors.
+func anchorLessons() []models.Lesson {
+	return []models.Lesson{
+		{ID: "dot", Solution: "c.t", Completed: true},
+		{ID: "anchors", Solution: `\bcat`, Completed: true},
+	}
+}
+
+// golfState has n practice problems, of which the first solved are solved
+// at par and the rest one character over.
+func golfState(n, solved int) State {
+	s := State{Solved: map[string]string{}}
+	for i := range n {
+		p := models.PracticeProblem{ID: fmt.Sprint(i), Solution: "a+b", Completed: true}
+		s.Practices = append(s.Practices, p)
+		if i < solved {
+			s.Solved[p.ExerciseID()] = "a+b"
+		} else {
+			s.Solved[p.ExerciseID()] = "a+bb"
+		}
+	}
+	return s
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name  string
+		badge string
+		state State
+		want  bool
+	}{
+		{"first try", "first-try", State{Summary: stats.Summary{Exercises: []stats.ExerciseStats{
+			{ID: "lesson/dot", Attempts: 3, Solved: true},
+			{ID: "lesson/anchors", Attempts: 1, Solved: true, FirstTry: true},
+		}}}, true},
+		{"solved but not first try", "first-try", State{Summary: stats.Summary{Exercises: []stats.ExerciseStats{
+			{ID: "lesson/dot", Attempts: 2, Solved: true},
+		}}}, false},
+
+		{"anchors without hints", "anchors-no-hints", State{Lessons: anchorLessons(), Summary: stats.Summary{Exercises: []stats.ExerciseStats{
+			{ID: "lesson/dot", HintsUsed: 2},
+		}}}, true},
+		{"anchors with a hint", "anchors-no-hints", State{Lessons: anchorLessons(), Summary: stats.Summary{Exercises: []stats.ExerciseStats{
+			{ID: "lesson/anchors", HintsUsed: 1},
+		}}}, false},
+		{"anchors not completed", "anchors-no-hints", State{Lessons: []models.Lesson{
+			{ID: "dot", Solution: "c.t", Completed: true},
+			{ID: "anchors", Solution: `\bcat`},
+		}}, false},
+
+		{"10 at par", "golf-par", golfState(12, 10), true},
+		{"9 at par", "golf-par", golfState(12, 9), false},
+
+		{"7-day streak", "week-streak", State{Summary: stats.Summary{LongestStreak: 7}}, true},
+		{"6-day streak", "week-streak", State{Summary: stats.Summary{LongestStreak: 6, CurrentStreak: 6}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := false
+			for _, b := range Check(tt.state, nil) {
+				if b.ID == tt.badge {
+					got = true
+				}
+			}
+			if got != tt.want {
+				t.Errorf("earned %s = %v, want %v", tt.badge, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckSkipsHeld(t *testing.T) {
+	s := State{Summary: stats.Summary{LongestStreak: 7}}
+	if got := Check(s, map[string]bool{"week-streak": true}); len(got) != 0 {
+		t.Errorf("Check = %v, want nothing new", got)
+	}
+}
+
+func TestLost(t *testing.T) {
+	s := golfState(10, 9)
+	s.Summary.LongestStreak = 7
+	lost := Lost(s, map[string]bool{"golf-par": true, "week-streak": true})
+	if len(lost) != 1 || lost[0].ID != "golf-par" {
+		t.Errorf("Lost = %v, want just golf-par", lost)
+	}
+}
+
+func TestParCount(t *testing.T) {
+	s := golfState(5, 3)
+	s.Solved[s.Practices[0].ExerciseID()] = "ab"
+	delete(s.Solved, s.Practices[1].ExerciseID())
+	if got := ParCount(s); got != 2 {
+		t.Errorf("ParCount = %d, want 2", got)
+	}
+}
@@ -0,0 +1,435 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ghousemohamed/regex-in-the-terminal/data"
+	"github.com/ghousemohamed/regex-in-the-terminal/models"
+	"github.com/ghousemohamed/regex-in-the-terminal/stats"
+	"github.com/ghousemohamed/regex-in-the-terminal/storage"
+	"github.com/ghousemohamed/regex-in-the-terminal/transcript"
+)
+
+// runHistory implements `learn-regex history`, which prints past attempts
+// as a table, oldest first.
+func runHistory(store storage.Store, args []string) error {
+	fs := flag.NewFlagSet("history", flag.ContinueOnError)
+	exercise := fs.String("exercise", "", `only show attempts at this exercise, e.g. "lesson/grouping", or every exercise under a prefix such as "practice/"`)
+	failed := fs.Bool("failed", false, "only show failed attempts")
+	since := fs.Duration("since", 0, "only show attempts made within this long, e.g. 48h")
+	limit := fs.Int("n", 50, "show at most this many of the most recent attempts (0 for all)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	q := storage.HistoryQuery{
+		ExerciseID: *exercise,
+		FailedOnly: *failed,
+		Limit:      *limit,
+	}
+	if *since > 0 {
+		q.Since = time.Now().Add(-*since)
+	}
+
+	attempts, err := storage.History(store, q)
+	if err != nil {
+		return err
+	}
+	if len(attempts) == 0 {
+		fmt.Println("No attempts recorded yet.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "WHEN\tEXERCISE\tRESULT\tTIME\tPATTERN\tFAILING CASE")
+	for _, a := range attempts {
+		result := "pass"
+		if !a.Passed {
+			result = "fail"
+		}
+		failing := a.FailingCase
+		if !a.Passed && failing == "" {
+			failing = "(invalid pattern)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%q\t%s\n",
+			a.Time.Local().Format("2006-01-02 15:04"),
+			a.ExerciseID,
+			result,
+			a.Elapsed.Round(time.Second),
+			a.Pattern,
+			failing,
+		)
+	}
+	return w.Flush()
+}
+
+// runStats implements `learn-regex stats`, printing the same numbers as the
+// Stats screen, or JSON for reports with --json.
+func runStats(store storage.Store, args []string) error {
+	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "print the statistics as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	attempts, err := store.LoadAttempts()
+	if err != nil {
+		return err
+	}
+	progress, err := store.LoadProgress()
+	if err != nil {
+		return err
+	}
+	summary := stats.Compute(attempts, progress.HintsUsed,
+		statsExercises(data.GetLessons(), data.GetPracticeProblems()), time.Now())
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(summary)
+	}
+
+	fmt.Printf("Total time spent:    %s\n", formatDuration(summary.TotalTime()))
+	fmt.Printf("Patterns submitted:  %d\n", summary.Attempts)
+	fmt.Printf("Exercises solved:    %d\n", summary.Solved)
+	fmt.Printf("First-try success:   %.0f%%\n", summary.FirstTryRate*100)
+	fmt.Printf("Hints used:          %d\n", summary.HintsUsed)
+	fmt.Printf("Current streak:      %d days (longest %d)\n", summary.CurrentStreak, summary.LongestStreak)
+
+	if len(summary.Exercises) > 0 {
+		fmt.Println()
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "EXERCISE\tATTEMPTS\tSOLVED\tFIRST TRY\tHINTS")
+		for _, e := range summary.Exercises {
+			fmt.Fprintf(w, "%s\t%d\t%v\t%v\t%d\n", e.Title, e.Attempts, e.Solved, e.FirstTry, e.HintsUsed)
+		}
+		return w.Flush()
+	}
+	return nil
+}
+
+// runExport implements `learn-regex export`, which writes the profile as a
+// bundle that `learn-regex import` can read on another machine.
+func runExport(store storage.Store, profile string, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	output := fs.String("o", "-", `file to write the bundle to, or "-" for standard output`)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	bundle, err := storage.Export(store, profile)
+	if err != nil {
+		return err
+	}
+
+	if *output == "-" {
+		return storage.WriteBundle(os.Stdout, bundle)
+	}
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := storage.WriteBundle(f, bundle); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d attempts, %d solutions and %d notes to %s\n",
+		len(bundle.Attempts), len(bundle.Solved), len(bundle.Notes), *output)
+	return nil
+}
+
+// runImport implements `learn-regex import`, which merges a bundle into the
+// profile, or replaces the profile's progress with it given -replace.
+func runImport(store storage.Store, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	replace := fs.Bool("replace", false, "overwrite local progress and notes instead of merging")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: learn-regex import [-replace] <bundle.json | ->")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("expected one bundle file")
+	}
+
+	in := os.Stdin
+	if name := fs.Arg(0); name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	bundle, err := storage.ReadBundle(in)
+	if err != nil {
+		return err
+	}
+	result, err := storage.Import(store, bundle, *replace)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Imported %d new attempts, %d better solutions and %d notes from profile %q.\n",
+		result.Attempts, result.Solved, result.Notes, bundle.Profile)
+	return nil
+}
+
+// runReset implements `learn-regex reset`, which resets a single lesson or
+// practice problem, a whole track, or undoes the last reset.
+func runReset(store storage.Store, args []string) error {
+	fs := flag.NewFlagSet("reset", flag.ContinueOnError)
+	lesson := fs.String("lesson", "", `reset the lesson with this ID, e.g. "grouping"`)
+	problem := fs.String("problem", "", "reset the practice problem with this ID")
+	allLessons := fs.Bool("all-lessons", false, "reset every lesson")
+	allProblems := fs.Bool("all-problems", false, "reset every practice problem")
+	undo := fs.Bool("undo", false, "undo the last reset")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	chosen := 0
+	for _, set := range []bool{*lesson != "", *problem != "", *allLessons, *allProblems, *undo} {
+		if set {
+			chosen++
+		}
+	}
+	if chosen != 1 {
+		fs.Usage()
+		return fmt.Errorf("choose exactly one of -lesson, -problem, -all-lessons, -all-problems or -undo")
+	}
+
+	switch {
+	case *undo:
+		snapshot, err := storage.UndoReset(store)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Restored %s.\n", snapshot.Description)
+		return nil
+	case *allLessons:
+		if err := storage.ClearSpecificProgress(store, "learning"); err != nil {
+			return err
+		}
+	case *allProblems:
+		if err := storage.ClearSpecificProgress(store, "practice"); err != nil {
+			return err
+		}
+	case *lesson != "":
+		lessons := data.GetLessons()
+		i := lessonIndex(lessons, *lesson)
+		if i < 0 {
+			return fmt.Errorf("no lesson with ID %q", *lesson)
+		}
+		if err := storage.Reset(store, "learning", i, lessons[i].ExerciseID(), fmt.Sprintf("%q", lessons[i].Title)); err != nil {
+			return err
+		}
+	case *problem != "":
+		practices := data.GetPracticeProblems()
+		i := -1
+		for j, p := range practices {
+			if p.ID == *problem {
+				i = j
+			}
+		}
+		if i < 0 {
+			return fmt.Errorf("no practice problem with ID %q", *problem)
+		}
+		if err := storage.Reset(store, "practice", i, practices[i].ExerciseID(), fmt.Sprintf("%q", practices[i].Title)); err != nil {
+			return err
+		}
+	}
+
+	if err := revokeLostBadges(store); err != nil {
+		return err
+	}
+	fmt.Println("Progress reset. Run `learn-regex reset -undo` to bring it back.")
+	return nil
+}
+
+// writeTranscript exports the transcript of the given course state as
+// Markdown ("md") or HTML ("html") to path, signed with key unless it is
+// nil.
+func writeTranscript(store storage.Store, profile string, lessons []models.Lesson, practices []models.PracticeProblem, format, path string, key ed25519.PrivateKey) error {
+	attempts, err := store.LoadAttempts()
+	if err != nil {
+		return err
+	}
+	progress, err := store.LoadProgress()
+	if err != nil {
+		return err
+	}
+
+	t := transcript.Build(profile, lessons, practices, attempts, progress.Solved, time.Now())
+	var doc []byte
+	switch format {
+	case "md":
+		doc = transcript.Markdown(t, key)
+	case "html":
+		doc = transcript.HTML(t, key)
+	default:
+		return fmt.Errorf("unknown transcript format %q (want md or html)", format)
+	}
+	return os.WriteFile(path, doc, 0644)
+}
+
+// transcriptFileName is where a transcript is saved by default.
+func transcriptFileName(profile, format string) string {
+	return fmt.Sprintf("regex-transcript-%s.%s", profile, format)
+}
+
+// runTranscript implements `learn-regex transcript`, which exports the
+// course transcript, `learn-regex transcript keygen`, which makes a key to
+// sign transcripts with, and `learn-regex transcript verify`, which checks
+// a signed transcript.
+func runTranscript(store storage.Store, profile string, args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "keygen":
+			return runTranscriptKeygen(args[1:])
+		case "verify":
+			return runTranscriptVerify(args[1:])
+		}
+	}
+
+	fs := flag.NewFlagSet("transcript", flag.ContinueOnError)
+	format := fs.String("format", "md", "md for Markdown or html for a self-contained web page")
+	output := fs.String("o", "", "file to write (default regex-transcript-<profile>.<format>)")
+	keyFile := fs.String("key", "", "signing key from transcript keygen; without it the transcript is unsigned")
+	bundleFile := fs.String("bundle", "", "progress bundle, as written by export, to build the transcript from instead of the local profile")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var key ed25519.PrivateKey
+	if *keyFile != "" {
+		pem, err := os.ReadFile(*keyFile)
+		if err != nil {
+			return err
+		}
+		if key, err = transcript.ParsePrivateKey(pem); err != nil {
+			return fmt.Errorf("%s: %w", *keyFile, err)
+		}
+	}
+
+	if *bundleFile != "" {
+		f, err := os.Open(*bundleFile)
+		if err != nil {
+			return err
+		}
+		bundle, err := storage.ReadBundle(f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+		store = storage.NewMemoryStore()
+		if _, err := storage.Import(store, bundle, true); err != nil {
+			return err
+		}
+		profile = bundle.Profile
+	}
+
+	if *output == "" {
+		*output = transcriptFileName(profile, *format)
+	}
+
+	progress, err := store.LoadProgress()
+	if err != nil {
+		return err
+	}
+	lessons, practices := data.GetLessons(), data.GetPracticeProblems()
+	markCompleted(lessons, practices, progress)
+
+	if err := writeTranscript(store, profile, lessons, practices, *format, *output, key); err != nil {
+		return err
+	}
+	if key == nil {
+		fmt.Printf("Unsigned transcript written to %s\n", *output)
+	} else {
+		fmt.Printf("Signed transcript written to %s\n", *output)
+	}
+	return nil
+}
+
+// runTranscriptKeygen implements `learn-regex transcript keygen`, which
+// writes a new signing key and the public key to check its signatures.
+func runTranscriptKeygen(args []string) error {
+	fs := flag.NewFlagSet("transcript keygen", flag.ContinueOnError)
+	name := fs.String("o", "transcript-signing", "write the keys to <name>.key and <name>.pub")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	private, public, err := transcript.GenerateKey()
+	if err != nil {
+		return err
+	}
+	// Never overwrite a key that transcripts may already be signed with.
+	f, err := os.OpenFile(*name+".key", os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(private); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.WriteFile(*name+".pub", public, 0644); err != nil {
+		return err
+	}
+	fmt.Printf("Signing key written to %s.key; keep it away from learners.\n", *name)
+	fmt.Printf("Public key written to %s.pub; share it with whoever checks transcripts.\n", *name)
+	return nil
+}
+
+// runTranscriptVerify implements `learn-regex transcript verify`, which
+// checks that a transcript was signed with the private key of a public key
+// and not changed since.
+func runTranscriptVerify(args []string) error {
+	fs := flag.NewFlagSet("transcript verify", flag.ContinueOnError)
+	pubFile := fs.String("pub", "", "public key of whoever signed the transcript")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: learn-regex transcript verify -pub <key.pub> <file>")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *pubFile == "" || fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("expected a public key and one transcript")
+	}
+
+	pem, err := os.ReadFile(*pubFile)
+	if err != nil {
+		return err
+	}
+	pub, err := transcript.ParsePublicKey(pem)
+	if err != nil {
+		return fmt.Errorf("%s: %w", *pubFile, err)
+	}
+	doc, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if err := transcript.Verify(doc, pub); err != nil {
+		return err
+	}
+	fmt.Printf("%s is signed by %s and unmodified.\n", fs.Arg(0), *pubFile)
+	return nil
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/ghousemohamed/regex-in-the-terminal/theme"
+)
+
+// Config holds the preferences shared by every profile, read from Path.
+type Config struct {
+	// Theme names a built-in theme or one of Themes.
+	Theme  string                 `json:"theme,omitempty"`
+	Themes map[string]theme.Theme `json:"themes,omitempty"`
+	Keys   Keys                   `json:"keys,omitempty"`
+}
+
+// Keys remaps the keys of the app.
+type Keys struct {
+	// Preset is "default", "vim" or "emacs". Empty means "default".
+	Preset string `json:"preset,omitempty"`
+	// Bindings maps action names, such as "reset" or "hint", to the keys
+	// that trigger them, replacing those of the preset.
+	Bindings map[string][]string `json:"bindings,omitempty"`
+}
+
+// Path is $XDG_CONFIG_HOME/learn-regex/config.json, falling back to
+// ~/.config/learn-regex/config.json as the XDG spec says.
+func Path() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, "learn-regex", "config.json")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = os.Getenv("HOME")
+	}
+	return filepath.Join(home, ".config", "learn-regex", "config.json")
+}
+
+// Load reads the config file. A missing file is an empty config.
+func Load() (Config, error) {
+	var c Config
+	data, err := os.ReadFile(Path())
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return c, err
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("%s: %w", Path(), err)
+	}
+	return c, nil
+}
+
+// ResolveTheme returns the theme the config chooses, or name instead when
+// it is not empty.
+func (c Config) ResolveTheme(name string) (theme.Theme, error) {
+	if name == "" {
+		name = c.Theme
+	}
+	return theme.Resolve(name, c.Themes)
+}
@@ -0,0 +1,60 @@
+package daily
+
+import (
+	"hash/fnv"
+	"time"
+
+	"github.com/ghousemohamed/regex-in-the-terminal/models"
+)
+
+const dateLayout = "2006-01-02"
+
+// Key is the calendar date of t, which is all that decides the challenge.
+func Key(t time.Time) string {
+	return t.Format(dateLayout)
+}
+
+// Pick returns the index of the practice problem for the day of t, out of
+// count problems. It depends on nothing but the date, so everyone gets the
+// same problem on the same day without going online.
+func Pick(t time.Time, count int) int {
+	if count <= 0 {
+		return 0
+	}
+	h := fnv.New32a()
+	h.Write([]byte(Key(t)))
+	return int(h.Sum32() % uint32(count))
+}
+
+// Record counts the challenge of the day of now as solved. Solving it
+// again the same day changes nothing.
+func Record(d models.DailyStreak, now time.Time) models.DailyStreak {
+	today := Key(now)
+	switch d.LastSolved {
+	case today:
+		return d
+	case Key(now.AddDate(0, 0, -1)):
+		d.Streak++
+	default:
+		d.Streak = 1
+	}
+	d.LastSolved = today
+	if d.Streak > d.Longest {
+		d.Longest = d.Streak
+	}
+	return d
+}
+
+// Streak returns the streak as of now: it is still alive until the end of
+// the day after the last challenge solved.
+func Streak(d models.DailyStreak, now time.Time) int {
+	if d.LastSolved == Key(now) || d.LastSolved == Key(now.AddDate(0, 0, -1)) {
+		return d.Streak
+	}
+	return 0
+}
+
+// SolvedToday reports whether the challenge of the day of now is solved.
+func SolvedToday(d models.DailyStreak, now time.Time) bool {
+	return d.LastSolved == Key(now)
+}
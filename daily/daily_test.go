@@ -0,0 +1,51 @@
+package daily
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ghousemohamed/regex-in-the-terminal/models"
+)
+
+func TestRecord(t *testing.T) {
+	today := time.Date(2024, 5, 20, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		d    models.DailyStreak
+		now  time.Time
+		want models.DailyStreak
+	}{
+		{"first challenge", models.DailyStreak{}, today,
+			models.DailyStreak{LastSolved: "2024-05-20", Streak: 1, Longest: 1}},
+		{"again the same day", models.DailyStreak{LastSolved: "2024-05-20", Streak: 3, Longest: 5}, today,
+			models.DailyStreak{LastSolved: "2024-05-20", Streak: 3, Longest: 5}},
+		{"the day after", models.DailyStreak{LastSolved: "2024-05-19", Streak: 3, Longest: 5}, today,
+			models.DailyStreak{LastSolved: "2024-05-20", Streak: 4, Longest: 5}},
+		{"beats the longest", models.DailyStreak{LastSolved: "2024-05-19", Streak: 5, Longest: 5}, today,
+			models.DailyStreak{LastSolved: "2024-05-20", Streak: 6, Longest: 6}},
+		{"after a missed day", models.DailyStreak{LastSolved: "2024-05-18", Streak: 4, Longest: 5}, today,
+			models.DailyStreak{LastSolved: "2024-05-20", Streak: 1, Longest: 5}},
+		{"across a month", models.DailyStreak{LastSolved: "2024-04-30", Streak: 2, Longest: 2}, today.AddDate(0, 0, -19),
+			models.DailyStreak{LastSolved: "2024-05-01", Streak: 3, Longest: 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Record(tt.d, tt.now); got != tt.want {
+				t.Errorf("Record(%+v) = %+v, want %+v", tt.d, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStreak(t *testing.T) {
+	now := time.Date(2024, 5, 20, 23, 0, 0, 0, time.UTC)
+	d := models.DailyStreak{LastSolved: "2024-05-19", Streak: 4}
+	if got := Streak(d, now); got != 4 {
+		t.Errorf("streak the day after = %d, want 4", got)
+	}
+	if got := Streak(d, now.AddDate(0, 0, 1)); got != 0 {
+		t.Errorf("streak two days after = %d, want 0", got)
+	}
+}
@@ -0,0 +1,354 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/ghousemohamed/regex-in-the-terminal/achievements"
+	"github.com/ghousemohamed/regex-in-the-terminal/daily"
+	"github.com/ghousemohamed/regex-in-the-terminal/mastery"
+	"github.com/ghousemohamed/regex-in-the-terminal/models"
+	"github.com/ghousemohamed/regex-in-the-terminal/review"
+	"github.com/ghousemohamed/regex-in-the-terminal/stats"
+	"github.com/ghousemohamed/regex-in-the-terminal/storage"
+)
+
+// exercise is the part of a lesson or practice problem needed to present it
+// outside its own screen, as review mode does.
+type exercise struct {
+	id        string
+	title     string
+	prompt    string
+	solution  string
+	testCases []models.TestCase
+}
+
+func (m model) exercise(id string) (exercise, bool) {
+	for _, l := range m.lessons {
+		if l.ExerciseID() == id {
+			return exercise{id, l.Title, l.Task, l.Solution, l.TestCases}, true
+		}
+	}
+	for _, p := range m.practices {
+		if p.ExerciseID() == id {
+			return exercise{id, p.Title, p.Description + "\n\nExamples:\n" + p.Examples, p.Solution, p.TestCases}, true
+		}
+	}
+	return exercise{}, false
+}
+
+// startExercise resets the timer and attempt count used to grade the
+// exercise now on screen and the TOC cursor, and brings back its draft.
+func (m *model) startExercise() {
+	m.openedAt = time.Now()
+	m.attempts = 0
+	m.hintLevel = 0
+	m.hint = ""
+	m.cases = caseCheck{}
+	m.tocCursor = -1
+
+	id, _ := m.currentExerciseID()
+	m.input.Reset(m.drafts[id], m.submittedPatterns(id))
+}
+
+// submittedPatterns returns the patterns submitted for exercise id, oldest
+// first, for the input to recall.
+func (m model) submittedPatterns(id string) []string {
+	if id == "" {
+		return nil
+	}
+	attempts, _ := storage.History(m.store, storage.HistoryQuery{ExerciseID: id, Limit: 100})
+	patterns := make([]string, len(attempts))
+	for i, a := range attempts {
+		patterns[i] = a.Pattern
+	}
+	return patterns
+}
+
+// stashDraft keeps the pattern typed so far for the exercise on screen, to
+// be restored when the learner comes back to it. Call it before moving to
+// another exercise or screen.
+func (m *model) stashDraft() {
+	id, ok := m.currentExerciseID()
+	if !ok {
+		return
+	}
+	if draft := m.input.Value(); draft != "" {
+		m.drafts[id] = draft
+	} else {
+		delete(m.drafts, id)
+	}
+}
+
+// saveSession stashes the current draft and stores the drafts changed since
+// the last save, along with the screen to reopen on the next launch.
+func (m *model) saveSession() error {
+	m.stashDraft()
+
+	var session models.Session
+	switch m.state {
+	case models.Learning:
+		session.Screen = models.ScreenLearning
+	case models.Practicing:
+		session.Screen = models.ScreenPracticing
+	}
+	if session.Screen != "" {
+		session.Cursor = m.input.Position()
+	}
+	if err := storage.SaveSession(m.store, session, m.drafts, m.savedDrafts); err != nil {
+		return err
+	}
+	m.savedDrafts = maps.Clone(m.drafts)
+	return nil
+}
+
+// resumeSession reopens the screen the learner quit on, with the cursor
+// where it was.
+func (m *model) resumeSession(session models.Session) {
+	switch session.Screen {
+	case models.ScreenLearning:
+		m.state = models.Learning
+	case models.ScreenPracticing:
+		m.keepPracticeVisible()
+		m.state = models.Practicing
+	default:
+		return
+	}
+	m.startExercise()
+	m.input.SetCursor(session.Cursor)
+}
+
+// typingPattern reports whether an exercise with a pattern input is on
+// screen.
+func (m model) typingPattern() bool {
+	_, ok := m.currentExerciseID()
+	return ok
+}
+
+// currentExerciseID returns the ID of the exercise on screen, if any.
+func (m model) currentExerciseID() (string, bool) {
+	switch m.state {
+	case models.Learning:
+		return m.lessons[m.current].ExerciseID(), true
+	case models.Practicing:
+		return m.practices[m.practiceIndex].ExerciseID(), true
+	case models.Reviewing:
+		if len(m.reviewQueue) > 0 {
+			return m.reviewQueue[0], true
+		}
+	}
+	return "", false
+}
+
+// showHint reveals the next hint for the exercise on screen: first the
+// concepts its reference solution uses, then the solution itself.
+func (m *model) showHint() {
+	id, ok := m.currentExerciseID()
+	if !ok || m.hintLevel >= 2 {
+		return
+	}
+	ex, _ := m.exercise(id)
+
+	m.hintLevel++
+	m.noteSaveError(storage.RecordHint(m.store, id))
+
+	if m.hintLevel == 1 {
+		concepts := mastery.ConceptsOf(ex.solution)
+		if len(concepts) == 0 {
+			m.hint = "Hint: plain characters, perhaps pinned with ^ and $, are enough here"
+			return
+		}
+		names := make([]string, len(concepts))
+		for i, c := range concepts {
+			names[i] = string(c)
+		}
+		m.hint = "Hint: try using " + strings.Join(names, ", ") + fmt.Sprintf(" (press %s again to see a solution)", keyName(keys.Hint))
+		return
+	}
+	m.hint = "Hint: one solution is " + ex.solution
+}
+
+// statsExercises lists every exercise in course order for the stats
+// summary.
+func statsExercises(lessons []models.Lesson, practices []models.PracticeProblem) []stats.Exercise {
+	var exercises []stats.Exercise
+	for _, l := range lessons {
+		exercises = append(exercises, stats.Exercise{ID: l.ExerciseID(), Title: l.Title})
+	}
+	for _, p := range practices {
+		exercises = append(exercises, stats.Exercise{ID: p.ExerciseID(), Title: p.Title})
+	}
+	return exercises
+}
+
+// scheduleReview grades the exercise that was just solved and stores when
+// it should come back for review.
+func (m model) scheduleReview(id string) error {
+	progress, err := m.store.LoadProgress()
+	if err != nil {
+		return err
+	}
+	quality := review.Quality(time.Since(m.openedAt), m.attempts)
+	return storage.SaveReview(m.store, id, review.Schedule(progress.Reviews[id], quality, time.Now()))
+}
+
+// dueReviews returns the IDs of the exercises due for review today,
+// skipping any that no longer exist in the course content.
+func (m model) dueReviews(progress models.Progress) []string {
+	var due []string
+	for _, id := range review.Due(progress.Reviews, time.Now()) {
+		if _, ok := m.exercise(id); ok {
+			due = append(due, id)
+		}
+	}
+	return due
+}
+
+// saveProgress stores where the learner is and the completions made since
+// the progress was loaded or last saved.
+func (m *model) saveProgress() error {
+	err := storage.SaveProgress(m.store, m.current, m.practiceIndex, m.lessons, m.practices, m.saved)
+	if err == nil {
+		m.saved = storage.Completions(m.lessons, m.practices)
+	}
+	return err
+}
+
+// recordAttempt appends the pattern just submitted for exercise id, and the
+// outcome of evaluating it, to the attempt history, and lets the input
+// recall it.
+func (m *model) recordAttempt(id string, evalErr error) error {
+	m.input.Submitted(m.input.Value())
+
+	attempt := models.Attempt{
+		ExerciseID: id,
+		Pattern:    m.input.Value(),
+		Passed:     evalErr == nil,
+		Time:       time.Now(),
+		Elapsed:    time.Since(m.openedAt),
+	}
+
+	var tcErr testCaseError
+	if errors.As(evalErr, &tcErr) {
+		attempt.FailingCase = tcErr.testCase.Text
+	}
+	if evalErr != nil {
+		attempt.Error = evalErr.Error()
+	}
+
+	if err := m.store.RecordAttempt(attempt); err != nil || !attempt.Passed {
+		return err
+	}
+	if best, ok := m.solved[id]; !ok || utf8.RuneCountInString(attempt.Pattern) < utf8.RuneCountInString(best) {
+		m.solved[id] = attempt.Pattern
+	}
+	return storage.RecordSolution(m.store, id, attempt.Pattern)
+}
+
+// recordDaily extends the daily streak if practice problem i, just solved,
+// is today's challenge.
+func (m *model) recordDaily(i int) {
+	now := time.Now()
+	if i != daily.Pick(now, len(m.practices)) {
+		return
+	}
+
+	var streak models.DailyStreak
+	err := m.store.UpdateProgress(func(progress *models.Progress) {
+		progress.Daily = daily.Record(progress.Daily, now)
+		streak = progress.Daily
+	})
+	if err != nil {
+		m.noteSaveError(err)
+		return
+	}
+	m.notice = fmt.Sprintf("Daily challenge solved! Streak: %d days", streak.Streak)
+}
+
+// badgeState gathers what badges are judged on from the stored attempts
+// and progress, for the course given with its completion flags set.
+func badgeState(store storage.Store, lessons []models.Lesson, practices []models.PracticeProblem) (achievements.State, models.Progress, error) {
+	attempts, err := store.LoadAttempts()
+	if err != nil {
+		return achievements.State{}, models.Progress{}, err
+	}
+	progress, err := store.LoadProgress()
+	if err != nil {
+		return achievements.State{}, models.Progress{}, err
+	}
+	return achievements.State{
+		Lessons:   lessons,
+		Practices: practices,
+		Summary:   stats.Compute(attempts, progress.HintsUsed, statsExercises(lessons, practices), time.Now()),
+		Solved:    progress.Solved,
+	}, progress, nil
+}
+
+// heldBadges returns the IDs of the badges in progress.
+func heldBadges(progress models.Progress) map[string]bool {
+	have := map[string]bool{}
+	for id := range progress.Badges {
+		have[id] = true
+	}
+	return have
+}
+
+// checkAchievements is called after every completed exercise. It awards
+// the badges just earned and announces them.
+func (m *model) checkAchievements() {
+	state, progress, err := badgeState(m.store, m.lessons, m.practices)
+	if err != nil {
+		m.noteSaveError(err)
+		return
+	}
+	earned := achievements.Check(state, heldBadges(progress))
+	if len(earned) == 0 {
+		return
+	}
+
+	var ids, names []string
+	now := time.Now()
+	for _, b := range earned {
+		ids = append(ids, b.ID)
+		names = append(names, b.Icon+" "+b.Name)
+		m.badges[b.ID] = now
+	}
+	m.noteSaveError(storage.AwardBadges(m.store, ids, now))
+	notice := "🏆 Badge earned: " + strings.Join(names, ", ") + " • see Trophies on the main menu"
+	if m.notice != "" {
+		notice = m.notice + " • " + notice
+	}
+	m.notice = notice
+}
+
+// withBest adds the learner's shortest solution of the exercise on screen,
+// and its length as a golf score, below the left column.
+func (m model) withBest(leftCol string) string {
+	id, ok := m.currentExerciseID()
+	if !ok || m.solved[id] == "" {
+		return leftCol
+	}
+	best := m.solved[id]
+	return lipgloss.JoinVertical(lipgloss.Left,
+		leftCol,
+		lipgloss.NewStyle().
+			PaddingLeft(2).
+			PaddingTop(1).
+			Width(lipgloss.Width(leftCol)).
+			Render(completedStyle.Render(fmt.Sprintf("Your best: %s (%d chars)", best, utf8.RuneCountInString(best)))),
+	)
+}
+
+// noteSaveError keeps a failed write on screen until the next successful
+// progress save, instead of losing it silently.
+func (m *model) noteSaveError(err error) {
+	if err != nil {
+		m.saveErr = err
+	}
+}
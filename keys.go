@@ -0,0 +1,329 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/ghousemohamed/regex-in-the-terminal/config"
+	"github.com/ghousemohamed/regex-in-the-terminal/models"
+)
+
+// keyMap holds a binding for every action. The help shown on screen is
+// generated from it, so it always names the keys actually bound.
+type keyMap struct {
+	Up, Down, Select, Back, Next, Prev     key.Binding
+	Hint, Reset, Filter, Sort              key.Binding
+	HistoryPrev, HistoryNext, Undo, Redo   key.Binding
+	ScrollUp, ScrollDown, TOCUp, TOCDown   key.Binding
+	Contents, FreeRoam                     key.Binding
+	FlagI, FlagM, FlagS, FlagU, POSIX      key.Binding
+	NextMatch, PrevMatch                   key.Binding
+	ExportMarkdown, ExportHTML, Help, Quit key.Binding
+}
+
+// keys starts out as the default preset and is replaced in main with the
+// bindings of the config file.
+var keys keyMap
+
+// keyAction describes an action that can be bound in the config file.
+type keyAction struct {
+	name    string
+	desc    string
+	binding func(*keyMap) *key.Binding
+}
+
+var keyActions = []keyAction{
+	{"up", "move up", func(k *keyMap) *key.Binding { return &k.Up }},
+	{"down", "move down", func(k *keyMap) *key.Binding { return &k.Down }},
+	{"select", "select / submit pattern", func(k *keyMap) *key.Binding { return &k.Select }},
+	{"back", "main menu / cancel", func(k *keyMap) *key.Binding { return &k.Back }},
+	{"next", "next exercise", func(k *keyMap) *key.Binding { return &k.Next }},
+	{"prev", "previous exercise", func(k *keyMap) *key.Binding { return &k.Prev }},
+	{"hint", "hint", func(k *keyMap) *key.Binding { return &k.Hint }},
+	{"reset", "reset progress", func(k *keyMap) *key.Binding { return &k.Reset }},
+	{"filter", "filter problems / matched lines", func(k *keyMap) *key.Binding { return &k.Filter }},
+	{"sort", "sort problems", func(k *keyMap) *key.Binding { return &k.Sort }},
+	{"history_prev", "earlier pattern", func(k *keyMap) *key.Binding { return &k.HistoryPrev }},
+	{"history_next", "later pattern", func(k *keyMap) *key.Binding { return &k.HistoryNext }},
+	{"undo", "undo edit", func(k *keyMap) *key.Binding { return &k.Undo }},
+	{"redo", "redo edit", func(k *keyMap) *key.Binding { return &k.Redo }},
+	{"scroll_up", "scroll description up", func(k *keyMap) *key.Binding { return &k.ScrollUp }},
+	{"scroll_down", "scroll description down", func(k *keyMap) *key.Binding { return &k.ScrollDown }},
+	{"toc_up", "pick the entry above in contents", func(k *keyMap) *key.Binding { return &k.TOCUp }},
+	{"toc_down", "pick the entry below in contents", func(k *keyMap) *key.Binding { return &k.TOCDown }},
+	{"contents", "show contents (narrow)", func(k *keyMap) *key.Binding { return &k.Contents }},
+	{"free_roam", "toggle free roam (profiles)", func(k *keyMap) *key.Binding { return &k.FreeRoam }},
+	{"flag_i", "case-insensitive (playground)", func(k *keyMap) *key.Binding { return &k.FlagI }},
+	{"flag_m", "multi-line (playground)", func(k *keyMap) *key.Binding { return &k.FlagM }},
+	{"flag_s", "dot matches \\n (playground)", func(k *keyMap) *key.Binding { return &k.FlagS }},
+	{"flag_u", "ungreedy (playground)", func(k *keyMap) *key.Binding { return &k.FlagU }},
+	{"posix", "POSIX syntax (playground)", func(k *keyMap) *key.Binding { return &k.POSIX }},
+	{"next_match", "next match (playground)", func(k *keyMap) *key.Binding { return &k.NextMatch }},
+	{"prev_match", "previous match (playground)", func(k *keyMap) *key.Binding { return &k.PrevMatch }},
+	{"export_markdown", "Markdown transcript", func(k *keyMap) *key.Binding { return &k.ExportMarkdown }},
+	{"export_html", "HTML transcript", func(k *keyMap) *key.Binding { return &k.ExportHTML }},
+	{"help", "all keys", func(k *keyMap) *key.Binding { return &k.Help }},
+	{"quit", "save and quit", func(k *keyMap) *key.Binding { return &k.Quit }},
+}
+
+// keyPresets are the starting points for the config file's bindings. The
+// vim and emacs presets only list what they change from the default.
+var keyPresets = map[string]map[string][]string{
+	"default": {
+		"up":              {"up", "k"},
+		"down":            {"down", "j"},
+		"select":          {"enter"},
+		"back":            {"esc"},
+		"next":            {"tab"},
+		"prev":            {"shift+tab"},
+		"hint":            {"ctrl+g"},
+		"reset":           {"ctrl+r"},
+		"filter":          {"ctrl+l"},
+		"sort":            {"ctrl+o"},
+		"history_prev":    {"up"},
+		"history_next":    {"down"},
+		"undo":            {"ctrl+z"},
+		"redo":            {"ctrl+y"},
+		"scroll_up":       {"pgup"},
+		"scroll_down":     {"pgdown"},
+		"toc_up":          {"shift+up"},
+		"toc_down":        {"shift+down"},
+		"contents":        {"ctrl+t"},
+		"free_roam":       {"ctrl+t"},
+		"flag_i":          {"alt+i"},
+		"flag_m":          {"alt+m"},
+		"flag_s":          {"alt+s"},
+		"flag_u":          {"alt+u"},
+		"posix":           {"alt+x"},
+		"next_match":      {"alt+n"},
+		"prev_match":      {"alt+p"},
+		"export_markdown": {"m"},
+		"export_html":     {"h"},
+		"help":            {"?", "f1"},
+		"quit":            {"ctrl+c"},
+	},
+	"vim": {
+		"reset":       {"ctrl+x"},
+		"redo":        {"ctrl+r"},
+		"scroll_up":   {"ctrl+u", "pgup"},
+		"scroll_down": {"ctrl+d", "pgdown"},
+		"toc_up":      {"ctrl+y", "shift+up"},
+		"toc_down":    {"ctrl+e", "shift+down"},
+	},
+	"emacs": {
+		"up":           {"up", "ctrl+p"},
+		"down":         {"down", "ctrl+n"},
+		"back":         {"esc", "ctrl+g"},
+		"hint":         {"alt+h"},
+		"reset":        {"alt+r"},
+		"filter":       {"alt+l"},
+		"sort":         {"alt+o"},
+		"history_prev": {"up", "ctrl+p"},
+		"history_next": {"down", "ctrl+n"},
+		"undo":         {"ctrl+_", "ctrl+z"},
+		"scroll_up":    {"alt+v", "pgup"},
+		"scroll_down":  {"ctrl+v", "pgdown"},
+		"toc_up":       {"alt+p", "shift+up"},
+		"toc_down":     {"alt+n", "shift+down"},
+		"contents":     {"alt+t"},
+		"next_match":   {"alt+."},
+		"prev_match":   {"alt+,"},
+	},
+}
+
+// screenActions lists the actions each screen handles. Actions handled on
+// the same screen can't share a key, except the pairs in sharedKeys.
+var screenActions = map[string][]string{
+	"main menu": {"up", "down", "select", "back", "reset", "contents",
+		"scroll_up", "scroll_down", "toc_up", "toc_down", "help", "quit"},
+	"profiles": {"up", "down", "select", "back", "free_roam", "quit"},
+	"exercise": {"up", "down", "select", "back", "next", "prev", "hint", "reset", "filter", "sort",
+		"history_prev", "history_next", "undo", "redo", "scroll_up", "scroll_down",
+		"toc_up", "toc_down", "contents", "help", "quit"},
+	"playground": {"select", "back", "next", "prev", "filter", "undo", "redo",
+		"scroll_up", "scroll_down", "flag_i", "flag_m", "flag_s", "flag_u", "posix",
+		"next_match", "prev_match", "help", "quit"},
+	"congratulations": {"select", "back", "reset", "export_markdown", "export_html", "help", "quit"},
+}
+
+// sharedKeys are the actions meant to share keys on a screen: the arrows
+// recall earlier patterns while a pattern is being typed and move through
+// lists otherwise.
+var sharedKeys = map[[2]string]bool{
+	{"up", "history_prev"}:   true,
+	{"down", "history_next"}: true,
+}
+
+func init() {
+	keys, _ = newKeyMap(config.Keys{})
+}
+
+// newKeyMap builds the bindings from a preset and the overrides of the
+// config file.
+func newKeyMap(c config.Keys) (keyMap, error) {
+	preset := c.Preset
+	if preset == "" {
+		preset = "default"
+	}
+	changes, ok := keyPresets[preset]
+	if !ok {
+		return keyMap{}, fmt.Errorf("unknown key preset %q; choose one of default, vim, emacs", preset)
+	}
+
+	bound := map[string][]string{}
+	for name, k := range keyPresets["default"] {
+		bound[name] = k
+	}
+	for name, k := range changes {
+		bound[name] = k
+	}
+
+	var unknown []string
+	for name, k := range c.Bindings {
+		if _, ok := bound[name]; !ok {
+			unknown = append(unknown, name)
+			continue
+		}
+		if len(k) == 0 {
+			return keyMap{}, fmt.Errorf("no keys given for %q", name)
+		}
+		bound[name] = k
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return keyMap{}, fmt.Errorf("unknown key actions %s", strings.Join(unknown, ", "))
+	}
+
+	if err := checkClashes(bound); err != nil {
+		return keyMap{}, err
+	}
+
+	var k keyMap
+	for _, a := range keyActions {
+		*a.binding(&k) = key.NewBinding(
+			key.WithKeys(bound[a.name]...),
+			key.WithHelp(strings.Join(bound[a.name], "/"), a.desc),
+		)
+	}
+	return k, nil
+}
+
+// checkClashes returns an error naming two actions handled on the same
+// screen that are bound to the same key, unless they are meant to share it.
+func checkClashes(bound map[string][]string) error {
+	screens := make([]string, 0, len(screenActions))
+	for screen := range screenActions {
+		screens = append(screens, screen)
+	}
+	sort.Strings(screens)
+
+	for _, screen := range screens {
+		owner := map[string]string{}
+		for _, name := range screenActions[screen] {
+			for _, k := range bound[name] {
+				other, ok := owner[k]
+				if ok && other != name && !sharedKeys[[2]string{other, name}] && !sharedKeys[[2]string{name, other}] {
+					return fmt.Errorf("%q is bound to both %s and %s, which are both used on the %s screen", k, other, name, screen)
+				}
+				owner[k] = name
+			}
+		}
+	}
+	return nil
+}
+
+// keyName is how instructions on screen refer to the keys of b.
+func keyName(b key.Binding) string {
+	return b.Help().Key
+}
+
+// firstKey is the first of the keys of b, where there is no room for all.
+func firstKey(b key.Binding) string {
+	return b.Keys()[0]
+}
+
+// describe returns b with the description desc in help.
+func describe(b key.Binding, desc string) key.Binding {
+	b.SetHelp(b.Help().Key, desc)
+	return b
+}
+
+func newHelp() help.Model {
+	h := help.New()
+	h.Styles.ShortKey = incompletedStyle.Copy().Bold(true)
+	h.Styles.ShortDesc = incompletedStyle
+	h.Styles.ShortSeparator = lockedStyle
+	h.Styles.FullKey = incompletedStyle.Copy().Bold(true)
+	h.Styles.FullDesc = incompletedStyle
+	h.Styles.FullSeparator = lockedStyle
+	return h
+}
+
+// footer is the one-line help under an exercise screen.
+func footer(bindings ...key.Binding) string {
+	return newHelp().ShortHelpView(bindings)
+}
+
+// helpAllowed reports whether msg may open the help overlay. On screens
+// with a pattern input a printable help key is only taken as such while
+// the input is empty, since ? is part of regex syntax; no pattern can start
+// with it anyway.
+func (m model) helpAllowed(msg tea.KeyMsg) bool {
+	if !key.Matches(msg, keys.Help) {
+		return false
+	}
+	if m.typingPattern() && msg.Type == tea.KeyRunes {
+		return m.input.Value() == ""
+	}
+	return m.state != models.ChoosingProfile
+}
+
+func (m model) helpView(header string, totalWidth int) string {
+	groups := []struct {
+		title    string
+		bindings []key.Binding
+	}{
+		{"Moving around", []key.Binding{keys.Up, keys.Down, keys.Select, keys.Back, keys.Next, keys.Prev}},
+		{"Exercises", []key.Binding{keys.Hint, keys.Reset, keys.Filter, keys.Sort}},
+		{"Pattern", []key.Binding{keys.HistoryPrev, keys.HistoryNext, keys.Undo, keys.Redo}},
+		{"Scrolling", []key.Binding{keys.ScrollUp, keys.ScrollDown, keys.TOCUp, keys.TOCDown, keys.Contents}},
+		{"Playground", []key.Binding{keys.FlagI, keys.FlagM, keys.FlagS, keys.FlagU, keys.POSIX, keys.NextMatch, keys.PrevMatch}},
+		{"Other", []key.Binding{keys.FreeRoam, keys.ExportMarkdown, keys.ExportHTML, keys.Help, keys.Quit}},
+	}
+
+	h := newHelp()
+	var columns []string
+	for _, g := range groups {
+		columns = append(columns, lipgloss.NewStyle().MarginRight(4).Render(
+			selectedStyle.Render(g.title)+"\n"+h.FullHelpView([][]key.Binding{g.bindings})))
+	}
+
+	// As many columns side by side as fit, in rows.
+	var rows, row []string
+	for _, c := range columns {
+		if len(row) > 0 && lipgloss.Width(lipgloss.JoinHorizontal(lipgloss.Top, append(row, c)...)) > totalWidth-8 {
+			rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, row...), "")
+			row = nil
+		}
+		row = append(row, c)
+	}
+	rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, row...))
+	body := lipgloss.JoinVertical(lipgloss.Left, rows...)
+
+	return lipgloss.JoinVertical(lipgloss.Center,
+		header,
+		lipgloss.NewStyle().
+			Width(totalWidth-4).
+			Padding(1).
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(borderColor).
+			Render(gradientText("Keys")+"\n\n"+body+"\n\n"+
+				incompletedStyle.Render("Keys can be changed in "+config.Path()+" • press any key to close")),
+	)
+}
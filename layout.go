@@ -0,0 +1,561 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/ghousemohamed/regex-in-the-terminal/daily"
+	"github.com/ghousemohamed/regex-in-the-terminal/models"
+)
+
+// minViewportHeight keeps the description and TOC usable even when the
+// terminal is too short to fit everything.
+const minViewportHeight = 3
+
+// exerciseScreen is what the lesson, practice and review screens show: a
+// scrollable description on the left above the pinned input, and a
+// scrollable list on the right.
+type exerciseScreen struct {
+	// key identifies the exercise on screen, so that scrolling starts over
+	// when it changes.
+	key     string
+	content string
+
+	tocTitle string
+	// toc has one entry per line; tocCurrent is the entry to keep in view.
+	toc        []string
+	tocCurrent int
+	// position is where the exercise is in the list, for the drawer bar.
+	position string
+
+	help string
+}
+
+func (m model) exerciseScreen() (exerciseScreen, bool) {
+	var s exerciseScreen
+	switch m.state {
+	case models.Learning:
+		l := m.lessons[m.current]
+		s.key = l.ExerciseID()
+		s.position = fmt.Sprintf("Lesson %d of %d", m.current+1, len(m.lessons))
+		s.content = titleStyle.Render(l.Title) + "\n\n" +
+			lessonStyle.Render(l.Description) + "\n" +
+			lessonStyle.Render(l.Task)
+
+		s.tocTitle = gradientText("Table of Contents") + "\n"
+		for i, l := range m.lessons {
+			status := "○"
+			style := incompletedStyle
+
+			switch m.lessonStatus(i) {
+			case lessonCompleted:
+				status = "✓"
+				style = completedStyle
+			case lessonLocked:
+				status = "🔒"
+				style = lockedStyle
+			}
+
+			lessonTitle := fmt.Sprintf("%s Lesson %d: %s", status, i+1, l.Title)
+			if i == m.current {
+				lessonTitle += " (current)"
+				style = style.Bold(true)
+				s.tocCurrent = i
+			}
+			if i == m.tocCursor {
+				style = selectedStyle
+			}
+			s.toc = append(s.toc, style.Render(lessonTitle))
+		}
+		s.help = footer(keys.Reset, describe(keys.Next, "skip lesson"), describe(keys.Prev, "previous lesson"),
+			keys.Hint, describe(keys.Back, "main menu"), keys.Help)
+
+	case models.Practicing:
+		p := m.practices[m.practiceIndex]
+		title := p.Title
+		if m.practiceIndex == daily.Pick(time.Now(), len(m.practices)) {
+			title = "Daily Challenge · " + title
+		}
+		s.key = p.ExerciseID()
+		s.position = fmt.Sprintf("Problem %d of %d", m.practiceIndex+1, len(m.practices))
+		s.content = titleStyle.Render(title) + "\n\n" +
+			lessonStyle.Render(p.Description) + "\n" +
+			lessonStyle.Render("Examples:\n"+p.Examples)
+
+		s.tocTitle = gradientText("Practice Problems") + "\n" +
+			tagStyle.Render(fmt.Sprintf("filter: %s • sort: %s",
+				m.practiceFilters[m.practiceFilter].label, m.practiceSort)) + "\n"
+		for n, i := range m.visiblePractices() {
+			p := m.practices[i]
+			status := "○"
+			style := incompletedStyle
+
+			if p.Completed {
+				status = "✓"
+				style = completedStyle
+			}
+
+			problemTitle := fmt.Sprintf("%s Problem %d: %s", status, i+1, p.Title)
+			if i == m.practiceIndex {
+				problemTitle += " (current)"
+				style = style.Bold(true)
+				s.tocCurrent = n
+			}
+			if i == m.tocCursor {
+				style = selectedStyle
+			}
+			s.toc = append(s.toc, style.Render(problemTitle)+" "+tagStyle.Render(practiceTags(p)))
+		}
+		s.help = footer(keys.Reset, describe(keys.Next, "skip problem"), describe(keys.Prev, "previous problem"),
+			keys.Hint, describe(keys.Filter, "filter"), describe(keys.Sort, "sort"), describe(keys.Back, "main menu"), keys.Help)
+
+	case models.Reviewing:
+		if len(m.reviewQueue) == 0 {
+			return s, false
+		}
+		current, _ := m.exercise(m.reviewQueue[0])
+		s.key = "review:" + current.id
+		s.position = fmt.Sprintf("%d reviews left", len(m.reviewQueue))
+		s.content = titleStyle.Render("Review: "+current.title) + "\n\n" +
+			lessonStyle.Render(current.prompt)
+
+		s.tocTitle = gradientText("Due Today") + "\n" +
+			incompletedStyle.Render(fmt.Sprintf("%d left", len(m.reviewQueue))) + "\n"
+		for i, id := range m.reviewQueue {
+			ex, _ := m.exercise(id)
+			style := incompletedStyle
+			title := "○ " + ex.title
+			if i == 0 {
+				style = style.Bold(true)
+				title += " (current)"
+			}
+			s.toc = append(s.toc, style.Render(title))
+		}
+		s.help = footer(describe(keys.Next, "postpone to the end of the queue"), keys.Hint, describe(keys.Back, "main menu"), keys.Help)
+
+	default:
+		return s, false
+	}
+	return s, true
+}
+
+// header is the title bar shown on every screen, with the save error and
+// notice banners below it.
+func (m model) header(totalWidth int) string {
+	style := headerStyle.Copy().Width(totalWidth - 4) // subtract margin
+	if m.height > 0 && m.height < compactHeight {
+		style = style.Padding(0, 2)
+	}
+	header := style.Render(gradientText("「 Learn Regex in the Terminal 」"))
+	if m.quitting {
+		return header
+	}
+
+	if m.saveErr != nil {
+		header = lipgloss.JoinVertical(lipgloss.Center,
+			header,
+			errorStyle.Render(fmt.Sprintf("⚠ Couldn't save progress: %v", m.saveErr)),
+		)
+	}
+
+	if m.notice != "" {
+		header = lipgloss.JoinVertical(lipgloss.Center,
+			header,
+			successStyle.Render(m.notice),
+		)
+	}
+	return header
+}
+
+// layoutMode is how an exercise screen is arranged for the terminal width.
+type layoutMode int
+
+const (
+	// sideBySide puts the TOC to the right of the description.
+	sideBySide layoutMode = iota
+	// stacked puts the TOC below the description.
+	stacked
+	// drawer hides the TOC behind a one-line bar that ctrl+t opens above
+	// the description.
+	drawer
+)
+
+// Breakpoints for the layout modes, and the smallest terminal anything is
+// drawn in.
+const (
+	sideBySideWidth = 100
+	stackedWidth    = 60
+	minWidth        = 40
+	minHeight       = 16
+	// compactHeight is the height below which the header loses its
+	// padding.
+	compactHeight = 30
+)
+
+// exerciseLayout is the size of each part of an exercise screen.
+type exerciseLayout struct {
+	mode layoutMode
+	// compact boxes have no vertical padding, to save lines when stacked.
+	compact bool
+	// showTOC is false while the drawer is closed.
+	showTOC bool
+
+	leftWidth, rightWidth int
+	// contentWidth and tocWidth are the widths inside the boxes.
+	contentWidth, tocWidth int
+	// contentHeight and tocHeight are the viewport heights, or 0 when the
+	// terminal height is unknown and everything is shown.
+	contentHeight, tocHeight int
+	// feedbackHeight is how many lines of feedback fit below the
+	// description box, or 0 when the terminal height is unknown.
+	feedbackHeight int
+}
+
+// contentStyle is the style of the description box.
+func (l exerciseLayout) contentStyle() lipgloss.Style {
+	style := mainContentStyle.Copy().Width(l.leftWidth - 6) // Account for borders and margin
+	if l.compact {
+		style = style.Padding(0, 1).MarginRight(0)
+	}
+	return style
+}
+
+// tocBoxStyle is the style of the TOC box.
+func (l exerciseLayout) tocBoxStyle() lipgloss.Style {
+	style := tocStyle.Copy().Width(l.rightWidth - 6)
+	if l.compact {
+		style = style.Padding(0, 1).MarginLeft(0)
+	}
+	return style
+}
+
+// join puts the description and TOC boxes side by side or one above the
+// other, as the mode has them when the TOC is shown.
+func (l exerciseLayout) join(left, right string) string {
+	switch l.mode {
+	case sideBySide:
+		return lipgloss.JoinHorizontal(lipgloss.Top, left, right)
+	case stacked:
+		return lipgloss.JoinVertical(lipgloss.Left, left, right)
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, right, left)
+}
+
+// columnLayout picks the layout mode for totalWidth and sizes the boxes
+// across. Other screens with a main box and a side box use it too, drawing
+// them with contentStyle and tocBoxStyle.
+func columnLayout(totalWidth int) exerciseLayout {
+	l := exerciseLayout{showTOC: true}
+	switch {
+	case totalWidth >= sideBySideWidth:
+		l.mode = sideBySide
+	case totalWidth >= stackedWidth:
+		l.mode = stacked
+	default:
+		l.mode = drawer
+	}
+	l.compact = l.mode != sideBySide
+
+	if l.mode == sideBySide {
+		l.leftWidth = (totalWidth * 60) / 100
+		l.rightWidth = (totalWidth * 40) / 100
+		// The boxes are given their width minus 6 for borders and margins,
+		// and have 2 columns of padding on either side.
+		l.contentWidth = max(l.leftWidth-6-4, 10)
+		l.tocWidth = max(l.rightWidth-6-4, 10)
+	} else {
+		// Both boxes span the screen, with 1 column of padding.
+		l.leftWidth = totalWidth
+		l.rightWidth = totalWidth
+		l.contentWidth = max(totalWidth-6-2, 10)
+		l.tocWidth = l.contentWidth
+	}
+	return l
+}
+
+func (m model) exerciseLayout(s exerciseScreen, totalWidth int) exerciseLayout {
+	l := columnLayout(totalWidth)
+	if l.mode == drawer {
+		l.showTOC = m.tocOpen
+	}
+
+	if m.height == 0 {
+		return l
+	}
+
+	// Everything but the boxes: the header, the blank line under it, and
+	// the help text below two blank lines.
+	help := lipgloss.NewStyle().Width(totalWidth).Render(s.help)
+	available := m.height - lipgloss.Height(m.header(totalWidth)) - 1 - 2 - lipgloss.Height(help)
+
+	// The description box holds the description, its scroll indicator, a
+	// blank line and the input, inside a border and, unless compact,
+	// padding. Feedback is pinned below the box.
+	boxFrame := 4
+	if l.compact {
+		boxFrame = 2
+	}
+	// Feedback is measured below an empty box as wide as the real one, so
+	// that it wraps the same way.
+	box := l.contentStyle().Render("")
+	feedback := lipgloss.Height(m.withFeedback(box)) - lipgloss.Height(box)
+
+	// The TOC box holds its title, the list and its scroll indicator. Side
+	// by side it is as tall as the screen allows; otherwise it takes up to
+	// a third of the screen and the description gets the rest.
+	tocTitle := lipgloss.Height(s.tocTitle)
+	if l.mode == sideBySide {
+		l.tocHeight = max(available-boxFrame-tocTitle-1, minViewportHeight)
+		l.feedbackHeight = max(available-boxFrame-3-minViewportHeight, 3)
+		l.contentHeight = max(available-boxFrame-3-min(feedback, l.feedbackHeight), minViewportHeight)
+		return l
+	}
+
+	tocTitle-- // no blank line under the title when compact
+	if l.showTOC {
+		l.tocHeight = min(len(s.toc), max(available/3-boxFrame-tocTitle-1, minViewportHeight))
+		// If the boxes and any feedback wouldn't fit, the TOC gives up
+		// lines, down to one.
+		room := available - boxFrame - tocTitle - l.tocHeight - 1 - boxFrame - 3 - minViewportHeight
+		if short := feedback - room; short > 0 {
+			l.tocHeight = max(l.tocHeight-short, 1)
+		}
+		available -= boxFrame + tocTitle + l.tocHeight + 1
+	} else {
+		available-- // the drawer bar
+	}
+	l.feedbackHeight = max(available-boxFrame-3-minViewportHeight, 3)
+	l.contentHeight = max(available-boxFrame-3-min(feedback, l.feedbackHeight), minViewportHeight)
+	return l
+}
+
+// syncViewports fits the viewports to the exercise on screen and the
+// terminal size. When the exercise changes it scrolls the description back
+// to the top and centers the current entry in the TOC.
+func (m *model) syncViewports() {
+	s, ok := m.exerciseScreen()
+	if !ok {
+		return
+	}
+	totalWidth := m.width
+	if totalWidth == 0 {
+		totalWidth = 120 // fallback width
+	}
+	l := m.exerciseLayout(s, totalWidth)
+
+	content := lipgloss.NewStyle().Width(l.contentWidth).Render(s.content)
+	m.contentView.Width = l.contentWidth
+	m.contentView.Height = lipgloss.Height(content)
+	if l.contentHeight > 0 {
+		m.contentView.Height = min(l.contentHeight, m.contentView.Height)
+	}
+	m.contentView.SetContent(content)
+
+	// The input is indented by one, and the cursor past the end of the
+	// pattern takes a cell of its own.
+	m.input.Width = max(l.contentWidth-1-lipgloss.Width(m.input.Prompt)-1, 1)
+
+	entries := make([]string, len(s.toc))
+	for i, entry := range s.toc {
+		entries[i] = lipgloss.NewStyle().MaxWidth(l.tocWidth).Render(entry)
+	}
+	m.tocView.Width = l.tocWidth
+	m.tocView.Height = len(entries)
+	if l.tocHeight > 0 {
+		m.tocView.Height = min(l.tocHeight, m.tocView.Height)
+	}
+	m.tocView.SetContent(strings.Join(entries, "\n"))
+
+	if s.key != m.viewKey {
+		m.viewKey = s.key
+		m.contentView.GotoTop()
+		m.tocView.SetYOffset(s.tocCurrent - m.tocView.Height/2)
+	}
+}
+
+// scroll moves the description for one of the scroll keys, or the TOC
+// cursor for one of the TOC keys. The review queue has no cursor, so there
+// the TOC keys scroll it instead.
+func (m *model) scroll(msg tea.KeyMsg) {
+	m.syncViewports()
+	review := m.state == models.Reviewing
+	switch {
+	case key.Matches(msg, keys.ScrollUp):
+		m.contentView.HalfViewUp()
+	case key.Matches(msg, keys.ScrollDown):
+		m.contentView.HalfViewDown()
+	case key.Matches(msg, keys.TOCUp) && review:
+		m.tocView.LineUp(1)
+	case key.Matches(msg, keys.TOCDown) && review:
+		m.tocView.LineDown(1)
+	case key.Matches(msg, keys.TOCUp):
+		m.moveTOCCursor(-1)
+	case key.Matches(msg, keys.TOCDown):
+		m.moveTOCCursor(1)
+	}
+}
+
+// tocEntries returns the index of the lesson or practice problem listed
+// on each line of the TOC.
+func (m model) tocEntries() []int {
+	if m.state == models.Practicing {
+		return m.visiblePractices()
+	}
+	entries := make([]int, len(m.lessons))
+	for i := range entries {
+		entries[i] = i
+	}
+	return entries
+}
+
+// moveTOCCursor moves the TOC cursor by delta entries, starting from the
+// exercise on screen if it isn't on the list, and scrolls the TOC to keep
+// it in view.
+func (m *model) moveTOCCursor(delta int) {
+	entries := m.tocEntries()
+	_, at := m.resetTrack()
+	n := position(entries, at)
+	if n < 0 {
+		n = 0
+	}
+	n = min(max(n+delta, 0), len(entries)-1)
+	m.tocCursor = entries[n]
+
+	switch {
+	case n < m.tocView.YOffset:
+		m.tocView.SetYOffset(n)
+	case n >= m.tocView.YOffset+m.tocView.Height:
+		m.tocView.SetYOffset(n - m.tocView.Height + 1)
+	}
+}
+
+// capHeight cuts s down to its first n lines, the last of which becomes an
+// ellipsis if anything is left out and there is room for one.
+func capHeight(s string, n int) string {
+	lines := strings.Split(s, "\n")
+	switch {
+	case len(lines) <= n:
+		return s
+	case n < 2:
+		return lines[0]
+	}
+	return strings.Join(lines[:n-1], "\n") + "\n" + tagStyle.Render("  … more if the window is taller")
+}
+
+// scrollIndicator tells whether there is more above or below what vp shows
+// and which keys scroll it, or is empty when everything fits.
+func scrollIndicator(vp viewport.Model, keys string) string {
+	if vp.TotalLineCount() <= vp.Height {
+		return ""
+	}
+	arrows := "↑↓"
+	switch {
+	case vp.AtTop():
+		arrows = " ↓"
+	case vp.AtBottom():
+		arrows = "↑ "
+	}
+	return incompletedStyle.Copy().
+		MaxWidth(vp.Width).
+		Render(fmt.Sprintf("%s %3.0f%% • %s", arrows, vp.ScrollPercent()*100, keys))
+}
+
+// withFeedback adds the error, test cases, hint and best solution for
+// the exercise on screen below the left column.
+func (m model) withFeedback(leftCol string) string {
+	if m.err != nil {
+		leftCol = lipgloss.JoinVertical(lipgloss.Left,
+			leftCol,
+			lipgloss.NewStyle().
+				PaddingLeft(2).
+				PaddingTop(1).
+				Width(lipgloss.Width(leftCol)).
+				Render(errorStyle.Render(m.err.Error())),
+		)
+	}
+	leftCol = m.withCases(leftCol)
+	if m.hint != "" {
+		leftCol = lipgloss.JoinVertical(lipgloss.Left,
+			leftCol,
+			lipgloss.NewStyle().
+				PaddingLeft(2).
+				PaddingTop(1).
+				Width(lipgloss.Width(leftCol)).
+				Render(hintStyle.Render(m.hint)),
+		)
+	}
+	return m.withBest(leftCol)
+}
+
+func (m model) exerciseView(header string, totalWidth int) string {
+	s, ok := m.exerciseScreen()
+	if !ok {
+		return header
+	}
+	m.syncViewports()
+	l := m.exerciseLayout(s, totalWidth)
+
+	tocTitle := s.tocTitle + "\n"
+	if l.compact {
+		tocTitle = s.tocTitle
+	}
+
+	var mainContent strings.Builder
+	mainContent.WriteString(m.contentView.View() + "\n")
+	mainContent.WriteString(scrollIndicator(m.contentView, firstKey(keys.ScrollUp)+"/"+firstKey(keys.ScrollDown)) + "\n\n")
+	mainContent.WriteString(lipgloss.NewStyle().
+		PaddingLeft(1).
+		Render(m.input.View()))
+
+	box := l.contentStyle().Render(mainContent.String())
+	leftCol := m.withFeedback(box)
+	if l.feedbackHeight > 0 {
+		leftCol = capHeight(leftCol, lipgloss.Height(box)+l.feedbackHeight)
+	}
+
+	var toc strings.Builder
+	toc.WriteString(tocTitle)
+	toc.WriteString(m.tocView.View() + "\n")
+	toc.WriteString(scrollIndicator(m.tocView, firstKey(keys.TOCUp)+"/"+firstKey(keys.TOCDown)))
+	rightCol := l.tocBoxStyle().Render(toc.String())
+
+	var columns string
+	switch {
+	case l.showTOC:
+		columns = l.join(leftCol, rightCol)
+	default:
+		bar := tagStyle.Copy().
+			MaxWidth(totalWidth - 4).
+			Render("☰ " + s.position + " • " + keyName(keys.Contents) + " for contents")
+		columns = lipgloss.JoinVertical(lipgloss.Left, bar, leftCol)
+	}
+
+	doc := strings.Builder{}
+	doc.WriteString(header + "\n")
+	doc.WriteString(lipgloss.NewStyle().
+		Align(lipgloss.Center).
+		Render(columns))
+	doc.WriteString("\n\n" + s.help + "\n")
+
+	return docStyle.Copy().Width(totalWidth).Render(doc.String())
+}
+
+// tooSmallView replaces every screen when the terminal is smaller than
+// minWidth by minHeight.
+func (m model) tooSmallView() string {
+	body := strings.Join([]string{
+		errorStyle.Render("Terminal too small"),
+		"",
+		fmt.Sprintf("%d×%d, need at least %d×%d", m.width, m.height, minWidth, minHeight),
+		"",
+		incompletedStyle.Render("Resize the window or press " + keyName(keys.Quit) + " to quit"),
+	}, "\n")
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center,
+		lipgloss.NewStyle().Width(m.width).Align(lipgloss.Center).Render(body))
+}
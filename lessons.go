@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/ghousemohamed/regex-in-the-terminal/models"
+)
+
+type lessonStatus int
+
+const (
+	lessonLocked lessonStatus = iota
+	lessonAvailable
+	lessonCompleted
+)
+
+func lessonIndex(lessons []models.Lesson, id string) int {
+	for i, l := range lessons {
+		if l.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// lessonUnlocked reports whether every prerequisite of lessons[i] has been
+// completed. Unknown prerequisite IDs are ignored so a typo in the content
+// can't lock a lesson forever.
+func lessonUnlocked(lessons []models.Lesson, i int) bool {
+	for _, id := range lessons[i].Prerequisites {
+		if j := lessonIndex(lessons, id); j >= 0 && !lessons[j].Completed {
+			return false
+		}
+	}
+	return true
+}
+
+func (m model) lessonStatus(i int) lessonStatus {
+	if m.lessons[i].Completed {
+		return lessonCompleted
+	}
+	if lessonUnlocked(m.lessons, i) {
+		return lessonAvailable
+	}
+	return lessonLocked
+}
+
+// canVisitLesson reports whether tab navigation may stop on lessons[i].
+func (m model) canVisitLesson(i int) bool {
+	return m.freeRoam || m.settings.FreeRoam || m.lessonStatus(i) != lessonLocked
+}
+
+// nextLesson returns the next visitable lesson after from, wrapping around.
+// It returns from when no other lesson can be visited.
+func (m model) nextLesson(from int) int {
+	for step := 1; step < len(m.lessons); step++ {
+		i := (from + step) % len(m.lessons)
+		if m.canVisitLesson(i) {
+			return i
+		}
+	}
+	return from
+}
+
+// prevLesson returns the closest visitable lesson before from, or from when
+// there is none.
+func (m model) prevLesson(from int) int {
+	for i := from - 1; i >= 0; i-- {
+		if m.canVisitLesson(i) {
+			return i
+		}
+	}
+	return from
+}
+
+// nextAvailableLesson returns the first unlocked, uncompleted lesson at or
+// after from, wrapping around. When everything reachable is done it falls
+// back to from.
+func (m model) nextAvailableLesson(from int) int {
+	for step := 0; step < len(m.lessons); step++ {
+		i := (from + step) % len(m.lessons)
+		if m.lessonStatus(i) == lessonAvailable {
+			return i
+		}
+	}
+	return from
+}
+
+// markCompleted sets the Completed flag of every lesson and practice problem
+// the stored progress lists as completed.
+func markCompleted(lessons []models.Lesson, practices []models.PracticeProblem, progress models.Progress) {
+	for _, lessonID := range progress.Completed {
+		var id int
+		if _, err := fmt.Sscanf(lessonID, "%d", &id); err == nil && id < len(lessons) {
+			lessons[id].Completed = true
+		}
+	}
+
+	for _, practiceID := range progress.CompletedPractice {
+		var id int
+		if _, err := fmt.Sscanf(practiceID, "%d", &id); err == nil && id < len(practices) {
+			practices[id].Completed = true
+		}
+	}
+}
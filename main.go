@@ -1,20 +1,31 @@
 package main
 
 import (
-	"encoding/json"
+	"flag"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
 	"regexp"
+	"regexp/syntax"
 	"strings"
+	"time"
 
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 
 	// Internal
+	"github.com/ghousemohamed/regex-in-the-terminal/achievements"
+	"github.com/ghousemohamed/regex-in-the-terminal/config"
+	"github.com/ghousemohamed/regex-in-the-terminal/daily"
 	"github.com/ghousemohamed/regex-in-the-terminal/data"
+	"github.com/ghousemohamed/regex-in-the-terminal/mastery"
 	"github.com/ghousemohamed/regex-in-the-terminal/models"
+	"github.com/ghousemohamed/regex-in-the-terminal/regexinput"
+	"github.com/ghousemohamed/regex-in-the-terminal/stats"
 	"github.com/ghousemohamed/regex-in-the-terminal/storage"
 )
 
@@ -23,172 +34,270 @@ type model struct {
 	practices       []models.PracticeProblem
 	current         int
 	practiceIndex   int
-	input           textinput.Model
+	input           regexinput.Model
 	err             error
 	width           int
 	height          int
 	quitting        bool
 	state           models.CompletionState
 	selectedOption  models.WelcomeOption
+	freeRoam        bool
+	practiceFilters []practiceFilter
+	practiceFilter  int
+	practiceSort    practiceSort
+	masteryScores   []mastery.Score
+	reviewQueue     []string
+	openedAt        time.Time
+	attempts        int
+	hintLevel       int
+	hint            string
+	// saved is the progress as last loaded or saved, for saveProgress.
+	saved         models.Progress
+	summary       stats.Summary
+	settings      models.Settings
+	profiles      []string
+	profileCursor int
+	profileInput  textinput.Model
+	saveErr       error
+	store         storage.Store
+	profile       string
+	backend       string
+	solved        map[string]string
+	drafts        map[string]string
+	// savedDrafts are the drafts as last loaded or saved, for saveSession.
+	savedDrafts     map[string]string
+	confirmingReset bool
+	resetOptions    []resetOption
+	resetCursor     int
+	notice          string
+	badges          map[string]time.Time
+	parCount        int
+	contentView     viewport.Model
+	tocView         viewport.Model
+	// tocCursor is the lesson or problem picked in the TOC with the TOC
+	// keys, which the reset dialog offers to reset, or -1 for none.
+	tocCursor   int
+	viewKey     string
+	tocOpen     bool
+	cases       caseCheck
+	showingHelp bool
+	playground  playground
+	// welcome is the progress the main menu shows and dueCount the reviews
+	// due, loaded by loadWelcome rather than on every render.
+	welcome  models.Progress
+	dueCount int
 }
 
+// The styles are set from the chosen theme by applyTheme.
 var (
-	
 	docStyle = lipgloss.NewStyle().
 		Align(lipgloss.Center)
 
-	titleStyle = lipgloss.NewStyle().
-		Bold(true).
-		Foreground(lipgloss.Color("#FAFAFA")).
-		Background(lipgloss.Color("#7B2CBF")).
-		Padding(0, 1).
-		MarginBottom(1)
-
-	lessonStyle = lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#4A5568")).
-		PaddingLeft(1)
-
-	tocStyle = lipgloss.NewStyle().
-		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("#874BFD")).
-		Padding(1, 2).
-		MarginLeft(2)
-
-	completedStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#10B981"))
-
-	incompletedStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#6B7280"))
-
-	errorStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#E11D48"))
-
-	successStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#10B981"))
-
-	headerStyle = lipgloss.NewStyle().
-		Bold(true).
-		Padding(1, 2).
-		Align(lipgloss.Center).
-		BorderStyle(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("#FF0080"))
-
-	gradientColors = []string{
-		"#FF0080",
-		"#FF359A",
-		"#FF65B5",
-		"#FF94D0",
-		"#FFC2EB",
-	}
-
-	mainContentStyle = lipgloss.NewStyle().
-		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("#7B2CBF")).
-		Padding(1, 2).
-		MarginRight(2)
+	titleStyle       lipgloss.Style
+	lessonStyle      lipgloss.Style
+	tocStyle         lipgloss.Style
+	completedStyle   lipgloss.Style
+	lockedStyle      lipgloss.Style
+	tagStyle         lipgloss.Style
+	incompletedStyle lipgloss.Style
+	errorStyle       lipgloss.Style
+	successStyle     lipgloss.Style
+	hintStyle        lipgloss.Style
+	headerStyle      lipgloss.Style
+	mainContentStyle lipgloss.Style
+	selectedStyle    lipgloss.Style
+
+	borderColor    lipgloss.Color
+	successColor   lipgloss.Color
+	gradientColors []string
 )
 
-var progressFile = filepath.Join(os.Getenv("HOME"), ".regex_tutorial_progress.json")
+// testCaseError reports the first test case a pattern got wrong.
+type testCaseError struct {
+	testCase models.TestCase
+}
+
+func (e testCaseError) Error() string {
+	if e.testCase.Expected {
+		return fmt.Sprintf("pattern should match '%s' but doesn't", e.testCase.Text)
+	}
+	return fmt.Sprintf("pattern shouldn't match '%s' but does", e.testCase.Text)
+}
+
+// compileError explains why pattern, parsed with flags, failed to compile
+// with err.
+func compileError(pattern string, flags syntax.Flags, err error) error {
+	if explained := regexinput.Explain(pattern, flags); explained != nil {
+		return explained
+	}
+	return fmt.Errorf("invalid regex pattern: %v", err)
+}
 
 func evaluateRegex(pattern string, testCases []models.TestCase) error {
 	re, err := regexp.Compile(pattern)
 	if err != nil {
-		return fmt.Errorf("invalid regex pattern: %v", err)
+		return compileError(pattern, syntax.Perl, err)
 	}
 
 	for _, tc := range testCases {
 		matches := re.MatchString(tc.Text)
 		if matches != tc.Expected {
-			if tc.Expected {
-				return fmt.Errorf("pattern should match '%s' but doesn't", tc.Text)
-			}
-			return fmt.Errorf("pattern shouldn't match '%s' but does", tc.Text)
+			return testCaseError{tc}
 		}
 	}
 	return nil
 }
 
-func initialModel() model {
-	ti := textinput.New()
+func initialModel(store storage.Store, profile, backend string) model {
+	ti := regexinput.New()
 	ti.Placeholder = "Enter your regex pattern"
+	ti.Styles = patternStyles
 	ti.Focus()
 
 	m := model{
-		lessons:   data.GetLessons(),
-		practices: data.GetPracticeProblems(),
-		input:     ti,
-		state:     models.Welcome,
+		lessons:    data.GetLessons(),
+		practices:  data.GetPracticeProblems(),
+		input:      ti,
+		state:      models.Welcome,
+		store:      store,
+		profile:    profile,
+		backend:    backend,
+		playground: newPlayground(),
 	}
-
-	if progress, err := storage.LoadProgress(); err == nil {
+	m.practiceFilters = practiceFilters(m.practices)
+	m.tocCursor = -1
+	m.settings, _ = store.LoadSettings()
+	m.solved = map[string]string{}
+	m.drafts = map[string]string{}
+	m.badges = map[string]time.Time{}
+
+	progress, err := store.LoadProgress()
+	if err != nil {
+		m.saveErr = err
+	} else {
+		for id, pattern := range progress.Solved {
+			m.solved[id] = pattern
+		}
 		m.current = progress.CurrentLesson
 		m.practiceIndex = progress.PracticeIndex
+		m.saved = progress
 
-		for _, lessonID := range progress.Completed {
-			var id int
-			if _, err := fmt.Sscanf(lessonID, "%d", &id); err == nil && id < len(m.lessons) {
-				m.lessons[id].Completed = true
-			}
-		}
+		markCompleted(m.lessons, m.practices, progress)
 
-		for _, practiceID := range progress.CompletedPractice {
-			var id int
-			if _, err := fmt.Sscanf(practiceID, "%d", &id); err == nil && id < len(m.practices) {
-				m.practices[id].Completed = true
-			}
+		for id, draft := range progress.Drafts {
+			m.drafts[id] = draft
+		}
+		m.savedDrafts = maps.Clone(m.drafts)
+		for id, at := range progress.Badges {
+			m.badges[id] = at
 		}
+		m.resumeSession(progress.Session)
 	}
+	m.loadWelcome()
 
 	return m
 }
 
 func (m model) Init() tea.Cmd {
+	if m.playground.loading {
+		return tea.Batch(textinput.Blink, m.playground.loader.next())
+	}
 	return textinput.Blink
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && m.state == models.ChoosingProfile && !key.Matches(keyMsg, keys.Quit) {
+		return m.updateProfiles(keyMsg)
+	}
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && m.confirmingReset && !key.Matches(keyMsg, keys.Quit) {
+		return m.updateReset(keyMsg)
+	}
+	if _, ok := msg.(tea.KeyMsg); ok && m.showingHelp {
+		// Any key closes the help.
+		m.showingHelp = false
+		return m, nil
+	}
+	if mouseMsg, ok := msg.(tea.MouseMsg); ok {
+		return m.updateMouse(mouseMsg)
+	}
+	if chunk, ok := msg.(loadChunkMsg); ok {
+		return m, m.addChunk(chunk)
+	}
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && m.state == models.Playground && !key.Matches(keyMsg, keys.Quit) {
+		return m.updatePlayground(keyMsg)
+	}
+	if _, ok := msg.(tea.KeyMsg); ok {
+		m.notice = ""
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c":
+		switch {
+		case key.Matches(msg, keys.Quit):
 			m.quitting = true
-			storage.SaveProgress(m.current, m.practiceIndex, m.lessons, m.practices)
+			m.saveErr = m.saveProgress()
+			m.noteSaveError(m.saveSession())
 			return m, tea.Quit
-		case "ctrl+r":
-			if m.state == models.Practicing {
-				storage.ClearSpecificProgress("practice")
-				newM := resetModel(m.width, m.height)
-
-				if progress, err := storage.LoadProgress(); err == nil {
-					newM.current = progress.CurrentLesson
-					for _, lessonID := range progress.Completed {
-						var id int
-						if _, err := fmt.Sscanf(lessonID, "%d", &id); err == nil && id < len(newM.lessons) {
-							newM.lessons[id].Completed = true
-						}
-					}
+		case key.Matches(msg, keys.Reset):
+			return m.openReset(), nil
+		case key.Matches(msg, keys.Hint):
+			m.showHint()
+			return m, nil
+		case key.Matches(msg, keys.Contents):
+			m.tocOpen = !m.tocOpen
+			m.syncViewports()
+			return m, nil
+		case key.Matches(msg, keys.ScrollUp, keys.ScrollDown, keys.TOCUp, keys.TOCDown):
+			m.scroll(msg)
+			return m, nil
+		case m.helpAllowed(msg):
+			m.showingHelp = true
+			return m, nil
+		case key.Matches(msg, keys.ExportMarkdown, keys.ExportHTML):
+			if m.state == models.Success {
+				format := "md"
+				if key.Matches(msg, keys.ExportHTML) {
+					format = "html"
 				}
-				newM.state = models.Practicing
-				return newM, nil
-			} else if m.state == models.Learning {
-				storage.ClearSpecificProgress("learning")
-				newM := resetModel(m.width, m.height)
-
-				if progress, err := storage.LoadProgress(); err == nil {
-					newM.practiceIndex = progress.PracticeIndex
-					for _, practiceID := range progress.CompletedPractice {
-						var id int
-						if _, err := fmt.Sscanf(practiceID, "%d", &id); err == nil && id < len(newM.practices) {
-							newM.practices[id].Completed = true
-						}
+				path := transcriptFileName(m.profile, format)
+				if err := writeTranscript(m.store, m.profile, m.lessons, m.practices, format, path, nil); err != nil {
+					m.err = err
+				} else {
+					m.err = nil
+					if abs, err := filepath.Abs(path); err == nil {
+						path = abs
 					}
+					m.notice = "Unsigned transcript saved to " + path
 				}
-				newM.state = models.Learning
-				return newM, nil
+				return m, nil
 			}
-		case "up", "k":
+		case key.Matches(msg, keys.Filter):
+			if m.state == models.Practicing {
+				m.practiceFilter = (m.practiceFilter + 1) % len(m.practiceFilters)
+				m.tocCursor = -1
+				m.keepPracticeVisible()
+				return m, nil
+			}
+		case key.Matches(msg, keys.Sort):
+			if m.state == models.Practicing {
+				m.practiceSort = (m.practiceSort + 1) % practiceSort(len(practiceSortLabels))
+				return m, nil
+			}
+		case key.Matches(msg, keys.HistoryPrev, keys.HistoryNext, keys.Undo, keys.Redo) && m.typingPattern():
+			switch {
+			case key.Matches(msg, keys.HistoryPrev):
+				m.input.Recall(-1)
+			case key.Matches(msg, keys.HistoryNext):
+				m.input.Recall(1)
+			case key.Matches(msg, keys.Undo):
+				m.input.Undo()
+			case key.Matches(msg, keys.Redo):
+				m.input.Redo()
+			}
+			return m, nil
+		case key.Matches(msg, keys.Up):
 			if m.state == models.Welcome {
 				if m.selectedOption > models.StartLearning {
 					m.selectedOption--
@@ -196,7 +305,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.selectedOption = models.Quit
 				}
 			}
-		case "down", "j":
+		case key.Matches(msg, keys.Down):
 			if m.state == models.Welcome {
 				if m.selectedOption < models.Quit {
 					m.selectedOption++
@@ -204,85 +313,121 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.selectedOption = 0
 				}
 			}
-		case "enter":
+		case key.Matches(msg, keys.Select):
 			if m.state == models.Success {
+				m.noteSaveError(m.saveSession())
 				return m, tea.Quit
 			}
 
 			if m.state == models.Welcome {
-				switch m.selectedOption {
-				case models.StartLearning:
-					m.state = models.Learning
-				case models.Practice:
+				return m.chooseWelcome()
+			}
+
+			if m.state == models.Mastery {
+				if m.practiceWeakSpots(mastery.Weak(m.masteryScores)) {
 					m.state = models.Practicing
-				case models.Quit:
-					m.quitting = true
-					return m, tea.Quit
+					m.err = nil
+					m.startExercise()
+				} else {
+					m.err = fmt.Errorf("no practice problems cover a weak concept")
+				}
+				return m, nil
+			}
+
+			if m.state == models.Reviewing {
+				if len(m.reviewQueue) == 0 {
+					m.showWelcome()
+					return m, nil
+				}
+
+				current, _ := m.exercise(m.reviewQueue[0])
+				m.attempts++
+				err := evaluateRegex(m.input.Value(), current.testCases)
+				m.noteSaveError(m.recordAttempt(current.id, err))
+				if err != nil {
+					m.err = err
+					m.cases = checkCases(m.input.Value(), current.testCases)
+				} else {
+					m.noteSaveError(m.scheduleReview(current.id))
+					delete(m.drafts, current.id)
+					m.checkAchievements()
+					m.reviewQueue = m.reviewQueue[1:]
+					m.startExercise()
+					m.err = nil
 				}
 				return m, nil
 			}
 
 			if m.state == models.Practicing {
-				if err := evaluateRegex(m.input.Value(), m.practices[m.practiceIndex].TestCases); err != nil {
+				m.attempts++
+				err := evaluateRegex(m.input.Value(), m.practices[m.practiceIndex].TestCases)
+				m.noteSaveError(m.recordAttempt(m.practices[m.practiceIndex].ExerciseID(), err))
+				if err != nil {
 					m.err = err
+					m.cases = checkCases(m.input.Value(), m.practices[m.practiceIndex].TestCases)
 				} else {
 					m.practices[m.practiceIndex].Completed = true
-					storage.SaveProgress(m.current, m.practiceIndex, m.lessons, m.practices)
-					if m.practiceIndex < len(m.practices)-1 {
-						m.practiceIndex++
-					}
-					m.input.SetValue("")
+					m.saveErr = m.saveProgress()
+					m.noteSaveError(m.scheduleReview(m.practices[m.practiceIndex].ExerciseID()))
+					delete(m.drafts, m.practices[m.practiceIndex].ExerciseID())
+					m.recordDaily(m.practiceIndex)
+					m.checkAchievements()
+					m.practiceIndex = m.nextPractice(m.practiceIndex, false)
+					m.startExercise()
 					m.err = nil
 				}
 				return m, nil
 			}
 
-			if err := evaluateRegex(m.input.Value(), m.lessons[m.current].TestCases); err != nil {
+			m.attempts++
+			err := evaluateRegex(m.input.Value(), m.lessons[m.current].TestCases)
+			m.noteSaveError(m.recordAttempt(m.lessons[m.current].ExerciseID(), err))
+			if err != nil {
 				m.err = err
+				m.cases = checkCases(m.input.Value(), m.lessons[m.current].TestCases)
+				m.input.SetValue("")
 			} else {
 				m.lessons[m.current].Completed = true
 				m.err = nil
-				storage.SaveProgress(m.current, m.practiceIndex, m.lessons, m.practices)
+				m.saveErr = m.saveProgress()
+				m.noteSaveError(m.scheduleReview(m.lessons[m.current].ExerciseID()))
+				delete(m.drafts, m.lessons[m.current].ExerciseID())
+				m.checkAchievements()
 				if getCompletedLessons(m) == len(m.lessons) {
 					m.state = models.Success
+					m.input.SetValue("")
 				} else {
-					if m.current == len(m.lessons) -1 {
-						m.current = 0
-					} else {
-						m.current++
-					}
+					m.current = m.nextAvailableLesson(m.current)
+					m.startExercise()
 				}
 			}
-			m.input.SetValue("")
-		case "tab":
+		case key.Matches(msg, keys.Next):
+			m.stashDraft()
 			if m.state == models.Learning {
-				if m.current == len(m.lessons) - 1 {
-					m.current = 0
-				} else {
-					m.current++
-				}
+				m.current = m.nextLesson(m.current)
 			} else if m.state == models.Practicing {
-				if m.practiceIndex == len(m.practices) - 1 {
-					m.practiceIndex = 0
-				} else {
-					m.practiceIndex++
-				}
+				m.practiceIndex = m.nextPractice(m.practiceIndex, true)
+			} else if m.state == models.Reviewing && len(m.reviewQueue) > 1 {
+				m.reviewQueue = append(m.reviewQueue[1:], m.reviewQueue[0])
 			}
-			m.input.SetValue("")
 			m.err = nil
-		case "shift+tab":
-			if m.state == models.Learning && m.current > 0 {
-				m.current--
-				m.input.SetValue("")
+			m.startExercise()
+		case key.Matches(msg, keys.Prev):
+			m.stashDraft()
+			if m.state == models.Learning && m.prevLesson(m.current) != m.current {
+				m.current = m.prevLesson(m.current)
 				m.err = nil
-			} else if m.state == models.Practicing && m.practiceIndex > 0 {
-				m.practiceIndex--
-				m.input.SetValue("")
+				m.startExercise()
+			} else if m.state == models.Practicing && m.prevPractice(m.practiceIndex) != m.practiceIndex {
+				m.practiceIndex = m.prevPractice(m.practiceIndex)
 				m.err = nil
+				m.startExercise()
 			}
-		case "esc":
-			if m.state == models.Learning || m.state == models.Practicing || m.state == models.Success {
-				m.state = models.Welcome
+		case key.Matches(msg, keys.Back):
+			if m.state == models.Learning || m.state == models.Practicing || m.state == models.Success || m.state == models.Mastery || m.state == models.Reviewing || m.state == models.Stats || m.state == models.Trophies {
+				m.stashDraft()
+				m.noteSaveError(m.saveSession())
+				m.showWelcome()
 				m.input.SetValue("")
 				m.err = nil
 			}
@@ -291,15 +436,30 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
+		// The layout may have changed, so bring the current exercise
+		// back into view.
+		m.viewKey = ""
+		m.syncViewports()
+		m.syncPlayground()
 	}
 
-	if m.state == models.Learning || m.state == models.Practicing {
+	if m.state == models.Learning || m.state == models.Practicing || m.state == models.Reviewing {
 		m.input, cmd = m.input.Update(msg)
 	}
+	if m.state == models.Playground {
+		// The cursors blink.
+		var textCmd tea.Cmd
+		m.playground.pattern, cmd = m.playground.pattern.Update(msg)
+		m.playground.text, textCmd = m.playground.text.Update(msg)
+		cmd = tea.Batch(cmd, textCmd)
+	}
 	return m, cmd
 }
 
 func gradientText(text string) string {
+	if noColor() || len(gradientColors) == 0 {
+		return text
+	}
 	words := strings.Split(text, "")
 	coloredText := make([]string, len(words))
 	
@@ -316,6 +476,91 @@ func gradientText(text string) string {
 	return strings.Join(coloredText, "")
 }
 
+// showWelcome returns to the main menu.
+func (m *model) showWelcome() {
+	m.state = models.Welcome
+	m.loadWelcome()
+}
+
+// loadWelcome loads the progress the main menu shows, and the reviews due.
+// Call it when progress may have changed since the menu was last shown.
+func (m *model) loadWelcome() {
+	progress, err := m.store.LoadProgress()
+	if err != nil {
+		return
+	}
+	m.welcome = progress
+	m.dueCount = len(m.dueReviews(progress))
+}
+
+// welcomeOptions are the labels of the main menu, in WelcomeOption order.
+func welcomeOptions(progress models.Progress) []string {
+	options := []string{
+		"Continue Learning",
+		"Practice Problems",
+		"Daily Challenge",
+		"Concept Mastery",
+		"Review",
+		"Playground",
+		"Stats",
+		"Trophies",
+		"Switch Profile",
+		"Quit",
+	}
+
+	hasProgress := len(progress.Completed) > 0 || progress.CurrentLesson > 0
+	if !hasProgress {
+		options[0] = "Start Learning"
+	}
+	return options
+}
+
+// chooseWelcome opens the main menu option that is selected, for the select
+// key or a click.
+func (m model) chooseWelcome() (tea.Model, tea.Cmd) {
+	switch m.selectedOption {
+	case models.StartLearning:
+		m.current = m.nextAvailableLesson(m.current)
+		m.state = models.Learning
+		m.startExercise()
+	case models.Practice:
+		m.keepPracticeVisible()
+		m.state = models.Practicing
+		m.startExercise()
+	case models.DailyChallenge:
+		m.practiceIndex = daily.Pick(time.Now(), len(m.practices))
+		m.state = models.Practicing
+		m.startExercise()
+	case models.ViewMastery:
+		attempts, _ := m.store.LoadAttempts()
+		m.masteryScores = mastery.Scores(attempts, mastery.ExerciseConcepts(m.lessons, m.practices))
+		m.state = models.Mastery
+	case models.Review:
+		progress, _ := m.store.LoadProgress()
+		m.reviewQueue = m.dueReviews(progress)
+		m.state = models.Reviewing
+		m.startExercise()
+	case models.OpenPlayground:
+		m.state = models.Playground
+		m.syncPlayground()
+		return m, m.playground.focusOn(m.playground.focus)
+	case models.ViewStats:
+		attempts, _ := m.store.LoadAttempts()
+		progress, _ := m.store.LoadProgress()
+		m.summary = stats.Compute(attempts, progress.HintsUsed, statsExercises(m.lessons, m.practices), time.Now())
+		m.state = models.Stats
+	case models.ViewTrophies:
+		m = m.openTrophies()
+	case models.SwitchProfile:
+		m = m.openProfiles()
+	case models.Quit:
+		m.quitting = true
+		m.noteSaveError(m.saveSession())
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
 func getCompletedLessons(m model) int {
 	completedLessons := 0
 	for _, lesson := range m.lessons {
@@ -332,19 +577,32 @@ func (m model) View() string {
 		totalWidth = 120 // fallback width
 	}
 
-	leftColumnWidth := (totalWidth * 60) / 100
-	rightColumnWidth := (totalWidth * 40) / 100
+	if !m.quitting && m.width > 0 && m.height > 0 && (m.width < minWidth || m.height < minHeight) {
+		return m.tooSmallView()
+	}
 
-	// Adjust header width
-	headerStyle := headerStyle.Copy().Width(totalWidth - 4) // subtract margin
-	headerText := "「 Learn Regex in the Terminal 」"
-	header := headerStyle.Render(gradientText(headerText))
+	header := m.header(totalWidth)
 
 	if m.quitting {
+		if m.saveErr != nil {
+			return header + "\n" + errorStyle.Render(fmt.Sprintf("Couldn't save progress: %v", m.saveErr)) + "\n"
+		}
 		return header + "\n" + "Progress saved\n"
 	}
 
+	if m.confirmingReset {
+		return m.resetView(header, totalWidth)
+	}
+
+	if m.showingHelp {
+		return m.helpView(header, totalWidth)
+	}
+
 	if m.state == models.Success {
+		var exportErr string
+		if m.err != nil {
+			exportErr = errorStyle.Render(m.err.Error())
+		}
 		successMsg := lipgloss.JoinVertical(lipgloss.Center,
 			"🎉 Congratulations! 🎉",
 			"",
@@ -352,10 +610,14 @@ func (m model) View() string {
 			"",
 			successStyle.Render("Final Stats:"),
 			fmt.Sprintf("Completed all %d lessons", len(m.lessons)),
+			fmt.Sprintf("Earned %d of %d badges", len(m.badges), len(achievements.Badges)),
+			m.badgeShelf(),
 			"",
 			"You're now ready to tackle real-world regex challenges!",
 			"",
-			lipgloss.NewStyle().Foreground(lipgloss.Color("#6B7280")).Render("Press ENTER to exit or ctrl+r to restart or Esc to go to main screen"),
+			exportErr,
+			incompletedStyle.Render(fmt.Sprintf("Press %s to export your transcript as Markdown or %s as HTML", keyName(keys.ExportMarkdown), keyName(keys.ExportHTML))),
+			incompletedStyle.Render(fmt.Sprintf("Press %s to exit or %s to restart or %s to go to main screen", keyName(keys.Select), keyName(keys.Reset), keyName(keys.Back))),
 		)
 
 		return lipgloss.JoinVertical(lipgloss.Center,
@@ -364,19 +626,44 @@ func (m model) View() string {
 				Width(totalWidth - 4).
 				Padding(2).
 				Border(lipgloss.RoundedBorder()).
-				BorderForeground(lipgloss.Color("#10B981")).
+				BorderForeground(successColor).
 				Align(lipgloss.Center).
 				Render(successMsg),
 		)
 	}
 
+	if m.state == models.Mastery {
+		return m.masteryView(header, totalWidth)
+	}
+
+	if m.state == models.Reviewing {
+		return m.reviewView(header, totalWidth)
+	}
+
+	if m.state == models.Stats {
+		return m.statsView(header, totalWidth)
+	}
+
+	if m.state == models.ChoosingProfile {
+		return m.profilesView(header, totalWidth)
+	}
+
+	if m.state == models.Trophies {
+		return m.trophiesView(header, totalWidth)
+	}
+
+	if m.state == models.Playground {
+		return m.playgroundView(header, totalWidth)
+	}
+
 	if m.state == models.Welcome {
-		progress, _ := storage.LoadProgress()
+		progress := m.welcome
 		hasLessonProgress := progress.CurrentLesson > 0 || len(progress.Completed) > 0
 		hasPracticeProgress := progress.PracticeIndex > 0 || len(progress.CompletedPractice) > 0
 
 		var welcomeMsg strings.Builder
 		welcomeMsg.WriteString(gradientText("Welcome to the Interactive Regex Tutorial!") + "\n\n")
+		welcomeMsg.WriteString(fmt.Sprintf("Profile: %s\n\n", m.profile))
 
 		if hasLessonProgress {
 			completedLessons := len(progress.Completed)
@@ -396,30 +683,31 @@ func (m model) View() string {
 				m.practices[progress.PracticeIndex].Title))
 		}
 
-		welcomeOptions := []string{
-			"Continue Learning",
-			"Practice Problems",
-			"Quit",
+		today := time.Now()
+		dailyProblem := m.practices[daily.Pick(today, len(m.practices))]
+		if daily.SolvedToday(progress.Daily, today) {
+			welcomeMsg.WriteString(fmt.Sprintf("Daily challenge: ✓ solved • streak %d days\n\n", daily.Streak(progress.Daily, today)))
+		} else {
+			welcomeMsg.WriteString(fmt.Sprintf("Daily challenge: %s • streak %d days\n\n", dailyProblem.Title, daily.Streak(progress.Daily, today)))
 		}
 
-		hasProgress := len(progress.Completed) > 0 || progress.CurrentLesson > 0
-		if !hasProgress {
-			welcomeOptions[0] = "Start Learning"
+		if m.dueCount > 0 {
+			welcomeMsg.WriteString(fmt.Sprintf("%d reviews due today\n\n", m.dueCount))
 		}
 
-		for i, option := range welcomeOptions {
+		for i, option := range welcomeOptions(progress) {
 			cursor := " "
 			if models.WelcomeOption(i) == m.selectedOption {
 				cursor = ">"
 			}
-			style := lipgloss.NewStyle().Foreground(lipgloss.Color("#6B7280"))
+			style := incompletedStyle
 			if models.WelcomeOption(i) == m.selectedOption {
-				style = style.Bold(true).Foreground(lipgloss.Color("#7B2CBF"))
+				style = selectedStyle
 			}
 			welcomeMsg.WriteString(fmt.Sprintf("%s %s\n", cursor, style.Render(option)))
 		}
 
-		welcomeMsg.WriteString("\nUse ↑/↓ arrows to select and Enter to confirm")
+		welcomeMsg.WriteString("\n" + footer(describe(keys.Up, "up"), describe(keys.Down, "down"), describe(keys.Select, "confirm"), keys.Help))
 
 		return lipgloss.JoinVertical(lipgloss.Center,
 			header,
@@ -427,205 +715,147 @@ func (m model) View() string {
 				Width(totalWidth - 4).
 				Padding(1).
 				Border(lipgloss.RoundedBorder()).
-				BorderForeground(lipgloss.Color("#874BFD")).
+				BorderForeground(borderColor).
 				Render(welcomeMsg.String()),
 		)
 	}
 
-	if m.state == models.Practicing {
-		totalWidth := m.width
-		if totalWidth == 0 {
-			totalWidth = 120 // fallback width
-		}
-
-		leftColumnWidth := (totalWidth * 60) / 100
-		rightColumnWidth := (totalWidth * 40) / 100
-
-		doc := strings.Builder{}
-		doc.WriteString(header + "\n")
-
-		// Left column content
-		var mainContent strings.Builder
-		currentProblem := m.practices[m.practiceIndex]
-		mainContent.WriteString(titleStyle.Render(currentProblem.Title) + "\n\n")
-		mainContent.WriteString(lessonStyle.Render(currentProblem.Description) + "\n")
-		mainContent.WriteString(lessonStyle.Render("Examples:\n" + currentProblem.Examples) + "\n\n")
-		mainContent.WriteString(lipgloss.NewStyle().
-			PaddingLeft(1).
-			Render(m.input.View()))
-
-		leftCol := mainContentStyle.
-			Width(leftColumnWidth - 6).
-			Render(mainContent.String())
-
-		if m.err != nil {
-			leftCol = lipgloss.JoinVertical(lipgloss.Left,
-				leftCol,
-				lipgloss.NewStyle().
-					PaddingLeft(2).
-					PaddingTop(1).
-					Render(errorStyle.Render(m.err.Error())),
-			)
-		}
-
-		// Right column (Practice Problems List)
-		tocStyle := tocStyle.Copy().Width(rightColumnWidth - 6)
-		var toc strings.Builder
-		toc.WriteString(gradientText("Practice Problems") + "\n\n")
-
-		for i, p := range m.practices {
-			status := "○"
-			style := incompletedStyle
-			
-			if p.Completed {
-				status = "✓"
-				style = completedStyle
-			}
-
-			problemTitle := fmt.Sprintf("%s Problem %d: %s", status, i+1, p.Title)
-			if i == m.practiceIndex {
-				problemTitle += " (current)"
-				style = style.Bold(true)
-				if !p.Completed {
-					status = "○"
-				}
-				problemTitle = fmt.Sprintf("%s Problem %d: %s (current)", status, i+1, p.Title)
-			}
-
-			toc.WriteString(style.Render(problemTitle) + "\n")
-		}
-
-		rightCol := tocStyle.Render(toc.String())
-
-		// Join columns
-		columns := lipgloss.JoinHorizontal(lipgloss.Top,
-			leftCol,
-			rightCol,
-		)
-
-		doc.WriteString(lipgloss.NewStyle().
-			Align(lipgloss.Center).
-			Render(columns))
-
-		doc.WriteString("\n\nPress ctrl+r to reset progress • tab to skip problem • shift+tab for previous problem • esc for main menu\n")
-
-		return docStyle.Copy().Width(totalWidth).Render(doc.String())
-	}
-
-	// Main tutorial view
-	doc := strings.Builder{}
-	doc.WriteString(header + "\n")
-
-	// Left column content
-	var mainContent strings.Builder
-	currentLesson := m.lessons[m.current]
-	mainContent.WriteString(titleStyle.Render(currentLesson.Title) + "\n\n")
-	mainContent.WriteString(lessonStyle.Render(currentLesson.Description) + "\n")
-	mainContent.WriteString(lessonStyle.Render(currentLesson.Task) + "\n\n")
-	mainContent.WriteString(lipgloss.NewStyle().
-		PaddingLeft(1).
-		Render(m.input.View()))
-
-	leftCol := mainContentStyle.
-		Width(leftColumnWidth - 6).  // Account for borders and margin
-		Render(mainContent.String())
-
-	if m.err != nil {
-		leftCol = lipgloss.JoinVertical(lipgloss.Left,
-			leftCol,
-			lipgloss.NewStyle().
-				PaddingLeft(2).
-				PaddingTop(1).
-				Render(errorStyle.Render(m.err.Error())),
-		)
-	}
-
-	// Right column (Table of Contents)
-	tocStyle := tocStyle.Copy().Width(rightColumnWidth - 6)  // Account for borders and margin
-	var toc strings.Builder
-	toc.WriteString(gradientText("Table of Contents") + "\n\n")
-
-	for i, l := range m.lessons {
-		status := "○"
-		style := incompletedStyle
-		
-		if l.Completed {
-			status = "✓"
-			style = completedStyle
-		}
-
-		lessonTitle := fmt.Sprintf("%s Lesson %d: %s", status, i+1, l.Title)
-		if i == m.current {
-			lessonTitle += " (current)"
-			style = style.Bold(true)
-			if !l.Completed {
-				status = "○"
-			}
-			lessonTitle = fmt.Sprintf("%s Lesson %d: %s (current)", status, i+1, l.Title)
-		}
-
-		toc.WriteString(style.Render(lessonTitle) + "\n")
-	}
-
-	rightCol := tocStyle.Render(toc.String())
-
-	// Join columns with proper spacing
-	columns := lipgloss.JoinHorizontal(lipgloss.Top,
-		leftCol,
-		rightCol,
-	)
-
-	doc.WriteString(lipgloss.NewStyle().
-		Align(lipgloss.Center).
-		Render(columns))
-
-	doc.WriteString("\n\nPress ctrl+r to reset progress • tab to skip lesson • shift+tab for previous lesson • esc for main menu\n")
-
-	return docStyle.Copy().Width(totalWidth).Render(doc.String())
+	return m.exerciseView(header, totalWidth)
 }
 
 // Add this function to create a new model while preserving dimensions
-func resetModel(width, height int) model {
-	m := initialModel()
-	m.width = width
-	m.height = height
+// and command-line options
+func resetModel(old model) model {
+	m := initialModel(old.store, old.profile, old.backend)
+	m.width = old.width
+	m.height = old.height
+	m.freeRoam = old.freeRoam
+	m.practiceFilters = old.practiceFilters
+	m.practiceFilter = old.practiceFilter
+	m.practiceSort = old.practiceSort
 	return m
 }
 
-func clearSpecificProgress(_ model, clearType string) error {
-	progress, err := storage.LoadProgress()
-	if err != nil && !os.IsNotExist(err) {
-		return err
+func main() {
+	freeRoam := flag.Bool("free-roam", false, "allow visiting lessons whose prerequisites are not completed")
+	profile := flag.String("profile", storage.DefaultProfile, "name of the learner profile to use")
+	backend := flag.String("store", "", "storage backend for the profile: json or sqlite (default: whichever the profile already uses, else json)")
+	themeName := flag.String("theme", "", "color theme: dark, light, high-contrast, colorblind-safe or one defined in the config file (default: the config file's, else dark)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [history|stats|export|import|reset|transcript|play]\n", os.Args[0])
+		flag.PrintDefaults()
 	}
+	flag.Parse()
 
-	if os.IsNotExist(err) {
-		progress = models.Progress{}
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "config: %v\n", err)
+		os.Exit(1)
 	}
-
-	if clearType == "practice" {
-		progress.PracticeIndex = 0
-		progress.CompletedPractice = nil
-	} else if clearType == "learning" {
-		progress.CurrentLesson = 0
-		progress.Completed = nil
+	t, err := cfg.ResolveTheme(*themeName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "config: %v\n", err)
+		os.Exit(1)
+	}
+	applyTheme(t)
+	if keys, err = newKeyMap(cfg.Keys); err != nil {
+		fmt.Fprintf(os.Stderr, "config: %v\n", err)
+		os.Exit(1)
 	}
 
-	data, err := json.Marshal(progress)
+	store, err := storage.Open(*profile, *backend)
 	if err != nil {
-		return err
+		fmt.Fprintf(os.Stderr, "profile: %v\n", err)
+		os.Exit(2)
 	}
-	
-	return os.WriteFile(progressFile, data, 0644)
-}
 
-func main() {
-	p := tea.NewProgram(
-		initialModel(),
-		tea.WithAltScreen(),
-		tea.WithMouseCellMotion(),
-	)
-	if _, err := p.Run(); err != nil {
+	var play *textLoader
+	options := []tea.ProgramOption{tea.WithAltScreen(), tea.WithMouseCellMotion()}
+	switch flag.Arg(0) {
+	case "":
+	case "play":
+		var stdin bool
+		play, stdin, err = openPlayText(flag.Args()[1:])
+		if err != nil {
+			store.Close()
+			fmt.Fprintf(os.Stderr, "play: %v\n", err)
+			os.Exit(1)
+		}
+		if stdin {
+			// The text comes in on stdin, so the keys have to be read
+			// from the terminal.
+			options = append(options, tea.WithInputTTY())
+		}
+	case "history":
+		err := runHistory(store, flag.Args()[1:])
+		store.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "history: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	case "stats":
+		err := runStats(store, flag.Args()[1:])
+		store.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "stats: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	case "export":
+		err := runExport(store, *profile, flag.Args()[1:])
+		store.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "export: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	case "import":
+		err := runImport(store, flag.Args()[1:])
+		store.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "import: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	case "reset":
+		err := runReset(store, flag.Args()[1:])
+		store.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "reset: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	case "transcript":
+		err := runTranscript(store, *profile, flag.Args()[1:])
+		store.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "transcript: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	m := initialModel(store, *profile, *backend)
+	m.freeRoam = *freeRoam
+	if flag.Arg(0) == "play" {
+		m.state = models.Playground
+		if play != nil {
+			m.playground.load(play)
+		}
+		m.syncPlayground()
+		m.playground.focusOn(focusPattern)
+	}
+
+	p := tea.NewProgram(m, options...)
+	final, err := p.Run()
+	if err != nil {
 		fmt.Printf("Error running program: %v", err)
 		os.Exit(1)
 	}
+	// Switching profiles replaces the store, so close whichever is open now.
+	final.(model).store.Close()
 }
\ No newline at end of file
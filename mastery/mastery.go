@@ -0,0 +1,166 @@
+package mastery
+
+import (
+	"regexp/syntax"
+	"sort"
+	"strings"
+
+	"github.com/ghousemohamed/regex-in-the-terminal/models"
+)
+
+// WeakThreshold is the score below which a concept counts as a weak spot.
+const WeakThreshold = 0.5
+
+type Score struct {
+	Concept   models.Concept
+	Exercises int
+	Attempts  int
+	Passes    int
+	Value     float64
+}
+
+// ConceptsOf parses pattern and reports which concepts it exercises. It
+// returns nil for patterns that don't compile.
+func ConceptsOf(pattern string) []models.Concept {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil
+	}
+
+	found := map[models.Concept]bool{}
+	if strings.Contains(pattern, `\p`) || strings.Contains(pattern, `\P`) {
+		found[models.ConceptUnicode] = true
+	}
+
+	var walk func(re *syntax.Regexp)
+	walk = func(re *syntax.Regexp) {
+		switch re.Op {
+		case syntax.OpBeginLine, syntax.OpEndLine, syntax.OpBeginText, syntax.OpEndText,
+			syntax.OpWordBoundary, syntax.OpNoWordBoundary:
+			found[models.ConceptAnchors] = true
+		case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+			found[models.ConceptQuantifiers] = true
+			// A quantifier over more than one character needs a group,
+			// even when the group itself is non-capturing.
+			switch re.Sub[0].Op {
+			case syntax.OpConcat, syntax.OpAlternate, syntax.OpCapture:
+				found[models.ConceptGroups] = true
+			case syntax.OpLiteral:
+				if len(re.Sub[0].Rune) > 1 {
+					found[models.ConceptGroups] = true
+				}
+			}
+		case syntax.OpCharClass, syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+			found[models.ConceptClasses] = true
+		case syntax.OpCapture:
+			found[models.ConceptGroups] = true
+		case syntax.OpAlternate:
+			found[models.ConceptAlternation] = true
+		case syntax.OpLiteral:
+			for _, r := range re.Rune {
+				if strings.ContainsRune(`\.+*?()|[]{}^$`, r) {
+					found[models.ConceptEscaping] = true
+				}
+			}
+		}
+		for _, sub := range re.Sub {
+			walk(sub)
+		}
+	}
+
+	// ^...$ around the whole pattern only pins it to the test text, so
+	// don't count it as using anchors.
+	subs := []*syntax.Regexp{re}
+	if n := len(re.Sub); re.Op == syntax.OpConcat && n > 2 &&
+		(re.Sub[0].Op == syntax.OpBeginText || re.Sub[0].Op == syntax.OpBeginLine) &&
+		(re.Sub[n-1].Op == syntax.OpEndText || re.Sub[n-1].Op == syntax.OpEndLine) {
+		subs = re.Sub[1 : n-1]
+	}
+	for _, sub := range subs {
+		walk(sub)
+	}
+
+	var concepts []models.Concept
+	for _, c := range models.Concepts {
+		if found[c] {
+			concepts = append(concepts, c)
+		}
+	}
+	return concepts
+}
+
+// ExerciseConcepts maps every exercise ID to its concepts. Practice problems
+// use their tags; lessons, which have none, fall back to the concepts of
+// their reference solution.
+func ExerciseConcepts(lessons []models.Lesson, practices []models.PracticeProblem) map[string][]models.Concept {
+	concepts := make(map[string][]models.Concept, len(lessons)+len(practices))
+	for _, l := range lessons {
+		concepts[l.ExerciseID()] = ConceptsOf(l.Solution)
+	}
+	for _, p := range practices {
+		if len(p.Tags) > 0 {
+			concepts[p.ExerciseID()] = p.Tags
+		} else {
+			concepts[p.ExerciseID()] = ConceptsOf(p.Solution)
+		}
+	}
+	return concepts
+}
+
+// Scores estimates mastery of each concept from the attempt history. Each
+// attempted exercise scores the share of its attempts that passed, and a
+// concept's value is the mean over the exercises that use it. Concepts with
+// no attempts are left out. The result is sorted weakest first.
+func Scores(attempts []models.Attempt, concepts map[string][]models.Concept) []Score {
+	type tally struct{ attempts, passes int }
+	perExercise := map[string]*tally{}
+	for _, a := range attempts {
+		t, ok := perExercise[a.ExerciseID]
+		if !ok {
+			t = &tally{}
+			perExercise[a.ExerciseID] = t
+		}
+		t.attempts++
+		if a.Passed {
+			t.passes++
+		}
+	}
+
+	byConcept := map[models.Concept]*Score{}
+	for id, t := range perExercise {
+		for _, c := range concepts[id] {
+			s, ok := byConcept[c]
+			if !ok {
+				s = &Score{Concept: c}
+				byConcept[c] = s
+			}
+			s.Exercises++
+			s.Attempts += t.attempts
+			s.Passes += t.passes
+			s.Value += float64(t.passes) / float64(t.attempts)
+		}
+	}
+
+	var scores []Score
+	for _, c := range models.Concepts {
+		if s, ok := byConcept[c]; ok {
+			s.Value /= float64(s.Exercises)
+			scores = append(scores, *s)
+		}
+	}
+	sort.SliceStable(scores, func(i, j int) bool {
+		return scores[i].Value < scores[j].Value
+	})
+	return scores
+}
+
+// Weak returns the concepts scoring below WeakThreshold.
+func Weak(scores []Score) []models.Concept {
+	var weak []models.Concept
+	for _, s := range scores {
+		if s.Value < WeakThreshold {
+			weak = append(weak, s.Concept)
+		}
+	}
+	return weak
+}
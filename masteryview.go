@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/ghousemohamed/regex-in-the-terminal/mastery"
+)
+
+func masteryBar(value float64, width int) string {
+	filled := int(value*float64(width) + 0.5)
+	return strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+}
+
+func (m model) masteryView(header string, totalWidth int) string {
+	var body strings.Builder
+	body.WriteString(gradientText("Concept Mastery") + "\n\n")
+
+	if len(m.masteryScores) == 0 {
+		body.WriteString("No attempts recorded yet. Work through a few lessons or problems first.\n")
+	}
+
+	for _, s := range m.masteryScores {
+		style := completedStyle
+		label := ""
+		if s.Value < mastery.WeakThreshold {
+			style = errorStyle
+			label = " (weak spot)"
+		}
+		body.WriteString(fmt.Sprintf("%-12s %s %3.0f%%  %s%s\n",
+			s.Concept,
+			style.Render(masteryBar(s.Value, 20)),
+			s.Value*100,
+			incompletedStyle.Render(fmt.Sprintf("%d exercises, %d/%d attempts passed", s.Exercises, s.Passes, s.Attempts)),
+			style.Render(label),
+		))
+	}
+
+	body.WriteString("\n")
+	if m.err != nil {
+		body.WriteString(errorStyle.Render(m.err.Error()) + "\n\n")
+	}
+	body.WriteString(incompletedStyle.
+		Render(fmt.Sprintf("Press %s to practice your weak spots or %s to go to main screen", keyName(keys.Select), keyName(keys.Back))))
+
+	return lipgloss.JoinVertical(lipgloss.Center,
+		header,
+		lipgloss.NewStyle().
+			Width(totalWidth-4).
+			Padding(1).
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(borderColor).
+			Render(body.String()),
+	)
+}
@@ -1,26 +1,86 @@
 package models
 
+import "time"
+
 type TestCase struct {
 	Text     string
 	Expected bool
 }
 
 type Lesson struct {
-	Title       string
-	Description string
-	Task        string
-	TestCases   []TestCase
-	Completed   bool
+	ID            string
+	Title         string
+	Description   string
+	Task          string
+	Solution      string
+	Prerequisites []string
+	TestCases     []TestCase
+	Completed     bool
 }
 
 type PracticeProblem struct {
+	ID          string
 	Title       string
 	Description string
 	Examples    string
+	Difficulty  Difficulty
+	Tags        []Concept
+	Solution    string
 	TestCases   []TestCase
 	Completed   bool
 }
 
+func (l Lesson) ExerciseID() string {
+	return "lesson/" + l.ID
+}
+
+func (p PracticeProblem) ExerciseID() string {
+	return "practice/" + p.ID
+}
+
+type Difficulty int
+
+const (
+	Easy Difficulty = iota
+	Medium
+	Hard
+)
+
+func (d Difficulty) String() string {
+	switch d {
+	case Easy:
+		return "easy"
+	case Medium:
+		return "medium"
+	case Hard:
+		return "hard"
+	}
+	return "unknown"
+}
+
+type Concept string
+
+const (
+	ConceptAnchors     Concept = "anchors"
+	ConceptQuantifiers Concept = "quantifiers"
+	ConceptClasses     Concept = "classes"
+	ConceptGroups      Concept = "groups"
+	ConceptAlternation Concept = "alternation"
+	ConceptEscaping    Concept = "escaping"
+	ConceptUnicode     Concept = "unicode"
+)
+
+// Concepts lists every concept tag in the order they are shown to learners.
+var Concepts = []Concept{
+	ConceptAnchors,
+	ConceptQuantifiers,
+	ConceptClasses,
+	ConceptGroups,
+	ConceptAlternation,
+	ConceptEscaping,
+	ConceptUnicode,
+}
+
 type CompletionState int
 
 const (
@@ -29,6 +89,12 @@ const (
 	Practicing
 	Completed
 	Success
+	Mastery
+	Reviewing
+	Stats
+	ChoosingProfile
+	Trophies
+	Playground
 )
 
 type WelcomeOption int
@@ -36,6 +102,13 @@ type WelcomeOption int
 const (
 	StartLearning WelcomeOption = iota
 	Practice
+	DailyChallenge
+	ViewMastery
+	Review
+	OpenPlayground
+	ViewStats
+	ViewTrophies
+	SwitchProfile
 	Quit
 )
 
@@ -44,4 +117,92 @@ type Progress struct {
 	Completed         []string `json:"completed_lessons"`
 	PracticeIndex     int      `json:"practice_index"`
 	CompletedPractice []string `json:"completed_practice"`
-}
\ No newline at end of file
+
+	Reviews   map[string]ReviewItem `json:"reviews,omitempty"`
+	HintsUsed map[string]int        `json:"hints_used,omitempty"`
+
+	// Solved holds the shortest passing pattern for each exercise ID. Its
+	// length is the learner's golf score for that exercise.
+	Solved map[string]string `json:"solved,omitempty"`
+
+	// Drafts holds the unsubmitted pattern of each exercise ID, and Session
+	// where the learner was when they last quit.
+	Drafts  map[string]string `json:"drafts,omitempty"`
+	Session Session           `json:"session"`
+
+	// LastReset is the progress as it was before the most recent reset,
+	// kept so that the reset can be undone.
+	LastReset *ResetSnapshot `json:"last_reset,omitempty"`
+
+	// Badges maps the ID of each achievement earned to when it was earned.
+	Badges map[string]time.Time `json:"badges,omitempty"`
+
+	Daily DailyStreak `json:"daily"`
+}
+
+// DailyStreak tracks the daily challenge. LastSolved is a date in
+// 2006-01-02 form.
+type DailyStreak struct {
+	LastSolved string `json:"last_solved,omitempty"`
+	Streak     int    `json:"streak,omitempty"`
+	Longest    int    `json:"longest,omitempty"`
+}
+
+// ResetSnapshot is the part of Progress a reset can change.
+type ResetSnapshot struct {
+	Description       string    `json:"description"`
+	Time              time.Time `json:"time"`
+	CurrentLesson     int       `json:"current_lesson"`
+	Completed         []string  `json:"completed_lessons"`
+	PracticeIndex     int       `json:"practice_index"`
+	CompletedPractice []string  `json:"completed_practice"`
+	// Reviews, Solved and Badges hold only the entries the reset removed.
+	Reviews map[string]ReviewItem `json:"reviews,omitempty"`
+	Solved  map[string]string     `json:"solved,omitempty"`
+	Badges  map[string]time.Time  `json:"badges,omitempty"`
+}
+
+// Screens a session can be resumed on.
+const (
+	ScreenLearning   = "learning"
+	ScreenPracticing = "practicing"
+)
+
+// Session is the screen and input cursor to reopen on the next launch. An
+// empty Screen opens the welcome screen.
+type Session struct {
+	Screen string `json:"screen,omitempty"`
+	Cursor int    `json:"cursor,omitempty"`
+}
+
+// ReviewItem is the spaced-repetition state of one completed exercise,
+// keyed by exercise ID in Progress.Reviews.
+type ReviewItem struct {
+	EaseFactor   float64   `json:"ease_factor"`
+	Interval     int       `json:"interval_days"`
+	Repetitions  int       `json:"repetitions"`
+	Due          time.Time `json:"due"`
+	LastReviewed time.Time `json:"last_reviewed"`
+}
+
+type Attempt struct {
+	ExerciseID string    `json:"exercise_id"`
+	Pattern    string    `json:"pattern"`
+	Passed     bool      `json:"passed"`
+	Time       time.Time `json:"time"`
+
+	// FailingCase is the text of the first test case the pattern got
+	// wrong, and Error the message shown to the learner.
+	FailingCase string `json:"failing_case,omitempty"`
+	Error       string `json:"error,omitempty"`
+
+	// Elapsed is the time between opening the exercise and submitting.
+	Elapsed time.Duration `json:"elapsed"`
+}
+
+// Settings are per-profile preferences.
+type Settings struct {
+	// FreeRoam lets the learner visit lessons whose prerequisites are not
+	// completed yet, like the --free-roam flag.
+	FreeRoam bool `json:"free_roam"`
+}
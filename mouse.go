@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/ghousemohamed/regex-in-the-terminal/models"
+)
+
+// tocEntryPattern finds the lesson or problem number in a line of the
+// rendered TOC.
+var tocEntryPattern = regexp.MustCompile(`(?:○|✓|🔒) (Lesson|Problem) (\d+):`)
+
+// updateMouse handles clicks and the scroll wheel. Rather than keep track
+// of where everything was drawn, it renders the screen again and looks at
+// the line under the pointer.
+func (m model) updateMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	if m.confirmingReset || m.state == models.ChoosingProfile {
+		return m, nil
+	}
+
+	lines := strings.Split(ansi.Strip(m.View()), "\n")
+	if msg.Y < 0 || msg.Y >= len(lines) {
+		return m, nil
+	}
+	line := lines[msg.Y]
+
+	switch {
+	case msg.Button == tea.MouseButtonWheelUp || msg.Button == tea.MouseButtonWheelDown:
+		if msg.Action != tea.MouseActionPress {
+			return m, nil
+		}
+		up := msg.Button == tea.MouseButtonWheelUp
+		m.syncViewports()
+		if m.state == models.Playground {
+			if up {
+				m.playground.scrollTo(m.playground.top - 1)
+			} else {
+				m.playground.scrollTo(m.playground.top + 1)
+			}
+		} else if m.overTOC(msg.X, msg.Y, lines) {
+			if up {
+				m.tocView.LineUp(1)
+			} else {
+				m.tocView.LineDown(1)
+			}
+		} else if up {
+			m.contentView.LineUp(1)
+		} else {
+			m.contentView.LineDown(1)
+		}
+		return m, nil
+
+	case msg.Button == tea.MouseButtonLeft && msg.Action == tea.MouseActionPress:
+		if m.state == models.Welcome {
+			return m.clickWelcome(line)
+		}
+		if _, ok := m.exerciseScreen(); !ok {
+			return m, nil
+		}
+		if match := caseLinePattern.FindStringSubmatch(line); match != nil && !m.overTOC(msg.X, msg.Y, lines) {
+			n, _ := strconv.Atoi(match[1])
+			if m.cases.selected == n-1 {
+				m.cases.selected = -1
+			} else if n-1 < len(m.cases.results) {
+				m.cases.selected = n - 1
+			}
+			return m, nil
+		}
+		if match := tocEntryPattern.FindStringSubmatch(line); match != nil && m.overTOC(msg.X, msg.Y, lines) {
+			n, _ := strconv.Atoi(match[2])
+			m.jumpTo(match[1], n-1)
+		}
+	}
+	return m, nil
+}
+
+// clickWelcome selects and opens the menu option on the clicked line.
+func (m model) clickWelcome(line string) (tea.Model, tea.Cmd) {
+	label := strings.TrimSpace(strings.Trim(strings.TrimSpace(line), "│"))
+	label = strings.TrimSpace(strings.TrimPrefix(label, ">"))
+
+	for i, option := range welcomeOptions(m.welcome) {
+		if label == option {
+			m.selectedOption = models.WelcomeOption(i)
+			return m.chooseWelcome()
+		}
+	}
+	return m, nil
+}
+
+// overTOC reports whether the cell at x, y of the rendered lines is in the
+// TOC box of an exercise screen.
+func (m model) overTOC(x, y int, lines []string) bool {
+	s, ok := m.exerciseScreen()
+	if !ok {
+		return false
+	}
+	totalWidth := m.width
+	if totalWidth == 0 {
+		totalWidth = 120 // fallback width
+	}
+	l := m.exerciseLayout(s, totalWidth)
+	if l.mode == sideBySide {
+		return x >= l.leftWidth
+	}
+	if !l.showTOC {
+		return false
+	}
+
+	// Stacked boxes are each a run of whole lines, from the top border
+	// just above the title to the next bottom border.
+	title := ansi.Strip(strings.SplitN(s.tocTitle, "\n", 2)[0])
+	top := -1
+	for i, line := range lines {
+		if strings.Contains(line, title) && strings.Contains(line, "│") {
+			top = i - 1
+			break
+		}
+	}
+	if top < 0 || y < top {
+		return false
+	}
+	for i := top + 1; i < len(lines) && i <= y; i++ {
+		if strings.HasPrefix(strings.TrimSpace(lines[i]), "╰") {
+			return i == y
+		}
+	}
+	return true
+}
+
+// jumpTo opens lesson or problem i, clicked in the TOC. Locked lessons stay
+// locked unless free roam is on.
+func (m *model) jumpTo(kind string, i int) {
+	switch {
+	case kind == "Lesson" && m.state == models.Learning && i >= 0 && i < len(m.lessons):
+		if !m.canVisitLesson(i) {
+			m.err = fmt.Errorf("lesson %d is locked until you complete the lessons before it", i+1)
+			return
+		}
+		m.stashDraft()
+		m.current = i
+	case kind == "Problem" && m.state == models.Practicing && i >= 0 && i < len(m.practices):
+		m.stashDraft()
+		m.practiceIndex = i
+	default:
+		return
+	}
+	m.err = nil
+	m.startExercise()
+}
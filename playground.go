@@ -0,0 +1,718 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/ghousemohamed/regex-in-the-terminal/regexinput"
+)
+
+// playgroundFocus is the part of the playground that takes the keys.
+type playgroundFocus int
+
+const (
+	focusPattern playgroundFocus = iota
+	focusText
+)
+
+// maxMatches caps the matches found in the text, so that a pattern like .
+// on a big file doesn't take all the memory.
+const maxMatches = 100000
+
+// playgroundFlags are the flags the playground toggles, in the order
+// they go in the (?flags) prefix.
+var playgroundFlags = []struct {
+	flag    string
+	desc    string
+	binding func(keyMap) key.Binding
+}{
+	{"i", "ignore case", func(k keyMap) key.Binding { return k.FlagI }},
+	{"m", "multi-line", func(k keyMap) key.Binding { return k.FlagM }},
+	{"s", "dot matches \\n", func(k keyMap) key.Binding { return k.FlagS }},
+	{"U", "ungreedy", func(k keyMap) key.Binding { return k.FlagU }},
+}
+
+// playground tries a pattern against any text, outside the exercises. The
+// text is typed in, or loaded from a file or stdin, in which case it can't
+// be edited.
+type playground struct {
+	pattern regexinput.Model
+	text    textarea.Model
+	focus   playgroundFocus
+	flags   map[string]bool
+	posix   bool
+
+	// loaded is set when the text comes from source rather than being
+	// typed. It is in body, which grows while loading.
+	loaded  bool
+	loading bool
+	source  string
+	loader  *textLoader
+	body    *strings.Builder
+	loadErr error
+
+	// lineStarts are the offsets in the text where each line starts.
+	lineStarts []int
+
+	err     error
+	re      *regexp.Regexp
+	matches [][]int
+	// matchedLines are the lines with a match, in order.
+	matchedLines []int
+	// compiledFor is what the matches were found for, so that they are
+	// only found again when it changes.
+	compiledFor string
+	// current is the match picked with next and previous match, or -1.
+	current int
+
+	// matchedOnly shows only the lines with a match. top is the first of
+	// the lines shown, and height how many fit in the preview.
+	matchedOnly bool
+	top         int
+	height      int
+}
+
+func newPlayground() playground {
+	pattern := regexinput.New()
+	pattern.Placeholder = "pattern to try"
+	pattern.Styles = patternStyles
+
+	text := textarea.New()
+	text.Placeholder = "Type or paste the text to match against"
+	text.Prompt = ""
+	text.ShowLineNumbers = false
+	text.CharLimit = 0
+	text.MaxHeight = 0
+
+	return playground{
+		pattern:    pattern,
+		text:       text,
+		flags:      map[string]bool{},
+		lineStarts: []int{0},
+		current:    -1,
+	}
+}
+
+// value is the text the pattern is matched against.
+func (p playground) value() string {
+	if p.loaded {
+		return p.body.String()
+	}
+	return p.text.Value()
+}
+
+// focusOn moves the keys to f. Loaded text can't be edited, so then the
+// pattern keeps them.
+func (p *playground) focusOn(f playgroundFocus) tea.Cmd {
+	if p.loaded {
+		f = focusPattern
+	}
+	p.focus = f
+	if f == focusPattern {
+		p.text.Blur()
+		return p.pattern.Focus()
+	}
+	p.pattern.Blur()
+	return p.text.Focus()
+}
+
+// prefix is the (?flags) group that turns on the chosen flags.
+func (p playground) prefix() string {
+	var flags string
+	for _, f := range playgroundFlags {
+		if p.flags[f.flag] {
+			flags += f.flag
+		}
+	}
+	if flags == "" {
+		return ""
+	}
+	return "(?" + flags + ")"
+}
+
+// indexLines records where the lines of text start, from offset from on.
+func (p *playground) indexLines(text string, from int) {
+	if from == 0 {
+		p.lineStarts = append(p.lineStarts[:0], 0)
+	}
+	for {
+		i := strings.IndexByte(text[from:], '\n')
+		if i < 0 {
+			return
+		}
+		from += i + 1
+		p.lineStarts = append(p.lineStarts, from)
+	}
+}
+
+// lineCount is how many lines the text has, not counting the empty one
+// after a final newline.
+func (p playground) lineCount() int {
+	n := len(p.lineStarts)
+	if n > 1 && p.lineStarts[n-1] == len(p.value()) {
+		n--
+	}
+	return n
+}
+
+// lineOf returns the line that offset is on.
+func (p playground) lineOf(offset int) int {
+	return sort.SearchInts(p.lineStarts, offset+1) - 1
+}
+
+// compile compiles the pattern and finds all its matches in the text.
+func (p *playground) compile() {
+	p.err, p.re, p.matches, p.matchedLines = nil, nil, nil, nil
+	p.current = -1
+	pattern := p.pattern.Value()
+	if pattern == "" {
+		return
+	}
+
+	var err error
+	if p.posix {
+		// POSIX syntax has no flags, so they are left out.
+		p.re, err = regexp.CompilePOSIX(pattern)
+	} else {
+		p.re, err = regexp.Compile(p.prefix() + pattern)
+	}
+	if err != nil {
+		p.err = compileError(pattern, p.pattern.Flags, err)
+		return
+	}
+	p.findMatches(0)
+}
+
+// findMatches adds the matches in the text from offset from on, which is
+// where a line starts.
+func (p *playground) findMatches(from int) {
+	if p.re == nil || len(p.matches) >= maxMatches {
+		return
+	}
+	for _, match := range p.re.FindAllStringSubmatchIndex(p.value()[from:], maxMatches-len(p.matches)) {
+		for i := range match {
+			if match[i] >= 0 {
+				match[i] += from
+			}
+		}
+		p.matches = append(p.matches, match)
+
+		// Every line the match covers, an empty match being on the line
+		// where it is.
+		first, last := p.lineOf(match[0]), p.lineOf(max(match[0], match[1]-1))
+		if n := len(p.matchedLines); n > 0 && p.matchedLines[n-1] >= first {
+			first = p.matchedLines[n-1] + 1
+		}
+		for line := first; line <= last; line++ {
+			p.matchedLines = append(p.matchedLines, line)
+		}
+	}
+}
+
+// load has the text read from l, in place of the typed text. Init starts
+// the reading.
+func (p *playground) load(l *textLoader) {
+	p.loaded, p.loading = true, true
+	p.source, p.loader = l.name, l
+	p.body = &strings.Builder{}
+	p.focusOn(focusPattern)
+}
+
+// addChunk appends a chunk of the text being loaded, and asks for the next
+// one until it is all there.
+func (m *model) addChunk(msg loadChunkMsg) tea.Cmd {
+	p := &m.playground
+	from := p.body.Len()
+	p.body.WriteString(msg.text)
+	p.indexLines(p.value(), from)
+	if msg.err != nil {
+		p.loadErr = msg.err
+	}
+	if !msg.done {
+		// Matching just the new lines keeps the count going while loading.
+		// A match across chunks, or a ^ or \A at the start of one, is sorted
+		// out once the whole text is in.
+		p.findMatches(from)
+		return p.loader.next()
+	}
+	p.loading = false
+	m.syncPlayground()
+	return nil
+}
+
+// shownLines is how many lines the preview can show.
+func (p playground) shownLines() int {
+	if p.matchedOnly {
+		return len(p.matchedLines)
+	}
+	return p.lineCount()
+}
+
+// shownLine returns the line the preview shows at position i.
+func (p playground) shownLine(i int) int {
+	if p.matchedOnly {
+		return p.matchedLines[i]
+	}
+	return i
+}
+
+// scrollTo sets the first line shown, stopping where the last line comes
+// into view.
+func (p *playground) scrollTo(top int) {
+	p.top = max(0, min(top, p.shownLines()-p.height))
+}
+
+// jumpToMatch picks the match delta after the current one, wrapping
+// around, and scrolls it into the middle of the preview.
+func (p *playground) jumpToMatch(delta int) {
+	if len(p.matches) == 0 {
+		return
+	}
+	switch {
+	case p.current < 0 && delta < 0:
+		p.current = len(p.matches) - 1
+	case p.current < 0:
+		p.current = 0
+	default:
+		p.current = (p.current + delta + len(p.matches)) % len(p.matches)
+	}
+
+	line := p.lineOf(p.matches[p.current][0])
+	if p.matchedOnly {
+		line = sort.SearchInts(p.matchedLines, line)
+	}
+	p.scrollTo(line - p.height/2)
+}
+
+func (m model) updatePlayground(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	p := &m.playground
+
+	switch {
+	case key.Matches(msg, keys.Back):
+		m.showWelcome()
+		return m, nil
+	case key.Matches(msg, keys.Help) && msg.Type != tea.KeyRunes:
+		m.showingHelp = true
+		return m, nil
+	case key.Matches(msg, keys.Next, keys.Prev):
+		cmd = p.focusOn(1 - p.focus)
+	case key.Matches(msg, keys.Select) && p.focus == focusPattern:
+		cmd = p.focusOn(focusText)
+	case key.Matches(msg, keys.ScrollUp):
+		p.scrollTo(p.top - max(1, p.height/2))
+		return m, nil
+	case key.Matches(msg, keys.ScrollDown):
+		p.scrollTo(p.top + max(1, p.height/2))
+		return m, nil
+	case key.Matches(msg, keys.NextMatch):
+		p.jumpToMatch(1)
+		return m, nil
+	case key.Matches(msg, keys.PrevMatch):
+		p.jumpToMatch(-1)
+		return m, nil
+	case key.Matches(msg, keys.Filter):
+		p.matchedOnly = !p.matchedOnly
+		p.top = 0
+		if p.current >= 0 {
+			p.jumpToMatch(0)
+		}
+		return m, nil
+	case key.Matches(msg, keys.FlagI, keys.FlagM, keys.FlagS, keys.FlagU):
+		for _, f := range playgroundFlags {
+			if key.Matches(msg, f.binding(keys)) {
+				p.flags[f.flag] = !p.flags[f.flag]
+			}
+		}
+	case key.Matches(msg, keys.POSIX):
+		p.posix = !p.posix
+		p.pattern.Flags = syntax.Perl
+		if p.posix {
+			p.pattern.Flags = syntax.POSIX
+		}
+	case key.Matches(msg, keys.Undo) && p.focus == focusPattern:
+		p.pattern.Undo()
+	case key.Matches(msg, keys.Redo) && p.focus == focusPattern:
+		p.pattern.Redo()
+	case p.focus == focusPattern:
+		p.pattern, cmd = p.pattern.Update(msg)
+	default:
+		p.text, cmd = p.text.Update(msg)
+	}
+
+	m.syncPlayground()
+	return m, cmd
+}
+
+// playgroundLayout is the size of each part of the playground.
+type playgroundLayout struct {
+	sideBySide   bool
+	onePane      bool
+	paneWidth    int
+	paneHeight   int
+	groupRows    int
+	contentWidth int
+	// errorHeight is how many lines of playgroundErrors are shown, and
+	// errorGap whether a blank line follows them.
+	errorHeight int
+	errorGap    bool
+}
+
+func (m model) playgroundLayout() playgroundLayout {
+	totalWidth, height := m.width, m.height
+	if totalWidth == 0 {
+		totalWidth = 120
+	}
+	if height == 0 {
+		height = 40
+	}
+	loaded := m.playground.loaded
+
+	l := playgroundLayout{
+		sideBySide:   totalWidth >= sideBySideWidth && !loaded,
+		contentWidth: totalWidth - 6,
+	}
+	l.paneWidth = l.contentWidth - 2
+	if l.sideBySide {
+		l.paneWidth = (l.contentWidth-2)/2 - 2
+	}
+
+	// Whatever is left after the header, the box around everything, the
+	// pattern, any error and the footer goes to the panes and the group
+	// table.
+	avail := height - lipgloss.Height(m.header(totalWidth)) - 4 -
+		lipgloss.Height(m.playgroundTop(l.contentWidth)) -
+		lipgloss.Height(lipgloss.NewStyle().Width(l.contentWidth).Render(m.playgroundFooter())) - 2
+	minPane := 2
+	if errs := m.playgroundErrors(l.contentWidth); errs != "" {
+		// The error goes above a blank line. It is cut short, losing the
+		// blank line last, to leave room for a pane of at least one line
+		// with its label and border.
+		room := avail - 3 - 1
+		l.errorHeight = min(lipgloss.Height(errs), max(room-1, 1))
+		l.errorGap = room > l.errorHeight
+		avail -= l.errorHeight
+		if l.errorGap {
+			avail--
+		}
+		minPane = 1
+	}
+	if l.sideBySide || loaded {
+		// Each pane has a label and a border, and a blank line comes
+		// before the table. Loaded text has only the preview pane.
+		avail -= 3 + 1
+		l.paneHeight = max(minPane, avail*2/3)
+		l.groupRows = max(0, avail-l.paneHeight)
+	} else if avail >= 2*3+1+1+2*3 {
+		avail -= 2*3 + 1 + 1
+		l.paneHeight = avail / 3
+		l.groupRows = avail - 2*l.paneHeight
+	} else {
+		// Only room for the pane with the focus.
+		l.onePane = true
+		l.paneHeight = max(minPane, avail-3)
+	}
+	return l
+}
+
+// syncPlayground compiles the pattern and fits the text and the preview
+// to the screen. Call it after anything in the playground changes.
+func (m *model) syncPlayground() {
+	p := &m.playground
+	compiledFor := fmt.Sprint(p.posix, p.prefix(), p.pattern.Value(), p.loading)
+	if !p.loaded {
+		compiledFor += p.text.Value()
+	}
+	if compiledFor != p.compiledFor {
+		if !p.loaded {
+			p.indexLines(p.value(), 0)
+		}
+		p.compile()
+		p.compiledFor = compiledFor
+	}
+
+	l := m.playgroundLayout()
+	// The cursor past the end of the pattern takes a cell of its own.
+	p.pattern.Width = max(l.contentWidth-lipgloss.Width(p.pattern.Prompt)-1, 1)
+	p.text.SetWidth(l.paneWidth)
+	p.text.SetHeight(l.paneHeight)
+	p.height = l.paneHeight
+	p.scrollTo(p.top)
+}
+
+// previewLine draws a line of the text with its matches highlighted, and
+// the current match in currentMatchStyle.
+func (p playground) previewLine(line int) string {
+	text := p.value()
+	start, end := p.lineStarts[line], len(text)
+	if line+1 < len(p.lineStarts) {
+		end = p.lineStarts[line+1] - 1
+	}
+	if end > start && text[end-1] == '\r' {
+		end--
+	}
+
+	var b strings.Builder
+	last := start
+	// Matches are in order, so the first that ends after the line starts
+	// is the first on it.
+	i := sort.Search(len(p.matches), func(i int) bool { return p.matches[i][1] > start })
+	for ; i < len(p.matches) && p.matches[i][0] < end; i++ {
+		from, to := max(p.matches[i][0], start), min(p.matches[i][1], end)
+		if from >= to {
+			continue
+		}
+		style := matchStyle
+		if i == p.current {
+			style = currentMatchStyle
+		}
+		b.WriteString(text[last:from])
+		b.WriteString(style.Render(text[from:to]))
+		last = to
+	}
+	b.WriteString(text[last:end])
+	return b.String()
+}
+
+// previewView draws the lines shown from top on, wrapped to width and cut
+// to height. Only the lines in view are drawn, so a big file costs no more
+// than a short text.
+func (m model) previewView(width, height int) string {
+	p := m.playground
+
+	// Line numbers tell where in a file, or among the matched lines, the
+	// preview is.
+	numbered := p.loaded || p.matchedOnly
+	gutter := len(strconv.Itoa(p.lineCount()))
+
+	var rows []string
+	for i := p.top; i < p.shownLines() && len(rows) < height; i++ {
+		line := p.shownLine(i)
+		text := p.previewLine(line)
+		if numbered {
+			text = lockedStyle.Render(fmt.Sprintf("%*d ", gutter, line+1)) + text
+		}
+		rows = append(rows, strings.Split(lipgloss.NewStyle().Width(width).Render(text), "\n")...)
+	}
+	rows = rows[:min(len(rows), height)]
+	for len(rows) < height {
+		rows = append(rows, "")
+	}
+	return strings.Join(rows, "\n")
+}
+
+// previewLabel counts the matches and the lines, and names the file the
+// text came from.
+func (p playground) previewLabel() string {
+	var parts []string
+	switch {
+	case p.re == nil:
+		parts = append(parts, "Matches")
+	case len(p.matches) >= maxMatches:
+		parts = append(parts, fmt.Sprintf("%d+ matches", maxMatches))
+	case len(p.matches) == 1:
+		parts = append(parts, "1 match")
+	default:
+		parts = append(parts, fmt.Sprintf("%d matches", len(p.matches)))
+	}
+	if p.current >= 0 {
+		parts[0] = fmt.Sprintf("Match %d of %s", p.current+1, parts[0])
+	}
+	if p.matchedOnly {
+		parts = append(parts, fmt.Sprintf("%d matched lines", len(p.matchedLines)))
+	} else if p.loaded {
+		parts = append(parts, fmt.Sprintf("%d lines", p.lineCount()))
+	}
+	if p.loading {
+		parts = append(parts, p.source+" (loading…)")
+	} else if p.loaded {
+		parts = append(parts, p.source)
+	}
+	return strings.Join(parts, " • ")
+}
+
+// playgroundTop is the title, the pattern and its flags.
+func (m model) playgroundTop(width int) string {
+	p := m.playground
+
+	var b strings.Builder
+	if m.height == 0 || m.height >= compactHeight {
+		b.WriteString(gradientText("Playground") + "\n\n")
+	}
+	b.WriteString(p.pattern.View() + "\n")
+
+	var flags []string
+	for _, f := range playgroundFlags {
+		box := "[ ]"
+		if p.flags[f.flag] {
+			box = "[x]"
+		}
+		if width < stackedWidth+20 {
+			// Just the letters where the descriptions would wrap.
+			flags = append(flags, box+" "+f.flag)
+		} else {
+			flags = append(flags, fmt.Sprintf("%s %s %s", box, f.flag, f.desc))
+		}
+	}
+	line := strings.Join(flags, "  ")
+	if p.posix {
+		line = lockedStyle.Render(line) + "  • CompilePOSIX (leftmost-longest, no flags)"
+	} else {
+		line = incompletedStyle.Render(line) + "  • Compile (RE2 syntax)"
+	}
+	b.WriteString(lipgloss.NewStyle().Width(width).Render(line))
+	return b.String()
+}
+
+// playgroundErrors is why the text couldn't be read or the pattern
+// compiled, if it couldn't, to show below playgroundTop.
+func (m model) playgroundErrors(width int) string {
+	p := m.playground
+	var errs []string
+	if p.loadErr != nil {
+		errs = append(errs, errorStyle.Copy().Width(width).Render(fmt.Sprintf("Couldn't read all of %s: %v", p.source, p.loadErr)))
+	}
+	if p.err != nil {
+		errs = append(errs, errorStyle.Copy().Width(width).Render(p.err.Error()))
+	}
+	return strings.Join(errs, "\n\n")
+}
+
+// pane draws content with a label above and a border that shows whether
+// it has the focus.
+func pane(label, content string, width int, focused bool) string {
+	var border lipgloss.TerminalColor = borderColor
+	if focused {
+		border = selectedStyle.GetForeground()
+	}
+	return lipgloss.JoinVertical(lipgloss.Left,
+		selectedStyle.Render(ansi.Truncate(label, width+2, "…")),
+		lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(border).
+			Width(width).
+			Render(content),
+	)
+}
+
+// groupTable lists each match with what every group captured, from the
+// current match on, in at most rows lines.
+func (m model) groupTable(rows int) string {
+	p := m.playground
+	if p.re == nil || rows < 2 {
+		return ""
+	}
+	text := p.value()
+	names := p.re.SubexpNames()
+	// Wide enough for the spans near the end of a big text.
+	spanWidth := max(9, 2*len(strconv.Itoa(len(text)))+1)
+	row := "%-6s %-14s %-" + strconv.Itoa(spanWidth) + "s %s"
+
+	lines := []string{selectedStyle.Render(fmt.Sprintf(row, "Match", "Group", "Span", "Text"))}
+	for i := max(p.current, 0); i < len(p.matches); i++ {
+		match := p.matches[i]
+		for g := 0; g < len(names); g++ {
+			number := ""
+			if g == 0 {
+				number = strconv.Itoa(i + 1)
+			}
+			group := strconv.Itoa(g)
+			if names[g] != "" {
+				group += " " + names[g]
+			}
+			start, end := match[2*g], match[2*g+1]
+			span, captured := "-", incompletedStyle.Render("no match")
+			if start >= 0 {
+				span = fmt.Sprintf("%d-%d", start, end)
+				captured = ansi.Truncate(strconv.Quote(text[start:end]), 40, "…")
+			}
+			lines = append(lines, fmt.Sprintf(row, number, ansi.Truncate(group, 14, "…"), span, captured))
+		}
+		if len(lines) > rows {
+			lines = append(lines[:rows-1], incompletedStyle.Render(fmt.Sprintf("… %d matches in all", len(p.matches))))
+			break
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (m model) playgroundFooter() string {
+	var bindings []key.Binding
+	if !m.playground.loaded {
+		focusHelp := "edit text"
+		if m.playground.focus == focusText {
+			focusHelp = "edit pattern"
+		}
+		bindings = append(bindings, describe(keys.Next, focusHelp))
+	}
+	return footer(append(bindings,
+		key.NewBinding(
+			key.WithKeys(firstKey(keys.FlagI)),
+			key.WithHelp(strings.Join([]string{keyName(keys.FlagI), keyName(keys.FlagM), keyName(keys.FlagS), keyName(keys.FlagU)}, "/"), "flags"),
+		),
+		describe(keys.POSIX, "POSIX"),
+		key.NewBinding(
+			key.WithKeys(firstKey(keys.NextMatch)),
+			key.WithHelp(keyName(keys.NextMatch)+"/"+keyName(keys.PrevMatch), "next/previous match"),
+		),
+		describe(keys.Filter, "matched lines only"),
+		describe(keys.ScrollDown, "scroll matches"),
+		describe(keys.Back, "main menu"),
+	)...)
+}
+
+func (m model) playgroundView(header string, totalWidth int) string {
+	p := m.playground
+	l := m.playgroundLayout()
+
+	textPane := pane("Text", p.text.View(), l.paneWidth, p.focus == focusText)
+	previewPane := pane(p.previewLabel(), m.previewView(l.paneWidth, l.paneHeight), l.paneWidth, false)
+	var panes string
+	switch {
+	case p.loaded:
+		panes = previewPane
+	case l.sideBySide:
+		panes = lipgloss.JoinHorizontal(lipgloss.Top, textPane, "  ", previewPane)
+	case !l.onePane:
+		panes = lipgloss.JoinVertical(lipgloss.Left, textPane, "", previewPane)
+	case p.focus == focusText:
+		panes = textPane
+	default:
+		panes = previewPane
+	}
+
+	var b strings.Builder
+	b.WriteString(m.playgroundTop(l.contentWidth) + "\n\n")
+	if errs := m.playgroundErrors(l.contentWidth); errs != "" {
+		b.WriteString(capHeight(errs, l.errorHeight) + "\n")
+		if l.errorGap {
+			b.WriteString("\n")
+		}
+	}
+	b.WriteString(panes + "\n")
+	if table := m.groupTable(l.groupRows); table != "" {
+		b.WriteString("\n" + table + "\n")
+	}
+
+	b.WriteString("\n" + m.playgroundFooter())
+
+	return lipgloss.JoinVertical(lipgloss.Center,
+		header,
+		lipgloss.NewStyle().
+			Width(totalWidth-4).
+			Padding(1).
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(borderColor).
+			Render(b.String()),
+	)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// chunkSize is about how much of the text is read at a time, so that a big
+// file shows up, and gets matched, bit by bit rather than all at the end.
+const chunkSize = 1 << 20
+
+// textLoader reads the text for the playground.
+type textLoader struct {
+	name string
+	r    *bufio.Reader
+	// file is nil when reading stdin, which is left open.
+	file *os.File
+}
+
+// loadChunkMsg is the next part of the text, made of whole lines but for
+// the last.
+type loadChunkMsg struct {
+	text string
+	done bool
+	err  error
+}
+
+// openPlayText implements the flags of `learn-regex play`, returning where
+// to read the text from, or nil to have it typed in. stdin reports whether
+// that is stdin, in which case keys have to come from the terminal.
+func openPlayText(args []string) (l *textLoader, stdin bool, err error) {
+	fs := flag.NewFlagSet("play", flag.ContinueOnError)
+	file := fs.String("f", "", `file with the text to match against, or "-" for stdin (default: stdin when it isn't a terminal)`)
+	if err := fs.Parse(args); err != nil {
+		return nil, false, err
+	}
+	if fs.NArg() > 0 {
+		return nil, false, fmt.Errorf("unexpected argument %q; give the file with -f", fs.Arg(0))
+	}
+
+	if *file != "" && *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return nil, false, err
+		}
+		return &textLoader{name: *file, r: bufio.NewReader(f), file: f}, false, nil
+	}
+	if *file == "" {
+		info, err := os.Stdin.Stat()
+		if err != nil {
+			return nil, false, err
+		}
+		if info.Mode()&os.ModeCharDevice != 0 {
+			return nil, false, nil
+		}
+	}
+	return &textLoader{name: "stdin", r: bufio.NewReader(os.Stdin)}, true, nil
+}
+
+// next reads the next chunk of the text.
+func (l *textLoader) next() tea.Cmd {
+	return func() tea.Msg {
+		buf := make([]byte, chunkSize)
+		n, err := l.r.Read(buf)
+		text := string(buf[:n])
+		if err == nil && n > 0 && buf[n-1] != '\n' {
+			// Read on to the end of the line, so that matching the chunk
+			// doesn't cut a line in two.
+			var rest string
+			rest, err = l.r.ReadString('\n')
+			text += rest
+		}
+		if err == nil {
+			return loadChunkMsg{text: text}
+		}
+		if l.file != nil {
+			l.file.Close()
+		}
+		if errors.Is(err, io.EOF) {
+			err = nil
+		}
+		return loadChunkMsg{text: text, done: true, err: err}
+	}
+}
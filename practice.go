@@ -0,0 +1,186 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/ghousemohamed/regex-in-the-terminal/models"
+)
+
+type practiceFilter struct {
+	label string
+	keep  func(models.PracticeProblem) bool
+}
+
+type practiceSort int
+
+const (
+	sortByListOrder practiceSort = iota
+	sortByDifficulty
+	sortByTitle
+)
+
+var practiceSortLabels = []string{"list order", "difficulty", "title"}
+
+func (s practiceSort) String() string {
+	return practiceSortLabels[s]
+}
+
+func hasConcept(p models.PracticeProblem, c models.Concept) bool {
+	for _, tag := range p.Tags {
+		if tag == c {
+			return true
+		}
+	}
+	return false
+}
+
+// practiceFilters returns the filters the filter key cycles through:
+// everything, then each difficulty, then each concept tag. Filters that
+// would leave the list empty are skipped.
+func practiceFilters(problems []models.PracticeProblem) []practiceFilter {
+	filters := []practiceFilter{{
+		label: "all",
+		keep:  func(models.PracticeProblem) bool { return true },
+	}}
+
+	var candidates []practiceFilter
+	for _, d := range []models.Difficulty{models.Easy, models.Medium, models.Hard} {
+		d := d
+		candidates = append(candidates, practiceFilter{
+			label: d.String(),
+			keep:  func(p models.PracticeProblem) bool { return p.Difficulty == d },
+		})
+	}
+	for _, c := range models.Concepts {
+		c := c
+		candidates = append(candidates, practiceFilter{
+			label: string(c),
+			keep:  func(p models.PracticeProblem) bool { return hasConcept(p, c) },
+		})
+	}
+
+	for _, f := range candidates {
+		for _, p := range problems {
+			if f.keep(p) {
+				filters = append(filters, f)
+				break
+			}
+		}
+	}
+	return filters
+}
+
+// visiblePractices returns the indices of the practice problems that pass
+// the active filter, in the active sort order.
+func (m model) visiblePractices() []int {
+	filter := m.practiceFilters[m.practiceFilter]
+
+	var visible []int
+	for i, p := range m.practices {
+		if filter.keep(p) {
+			visible = append(visible, i)
+		}
+	}
+
+	switch m.practiceSort {
+	case sortByDifficulty:
+		sort.SliceStable(visible, func(a, b int) bool {
+			return m.practices[visible[a]].Difficulty < m.practices[visible[b]].Difficulty
+		})
+	case sortByTitle:
+		sort.SliceStable(visible, func(a, b int) bool {
+			return strings.ToLower(m.practices[visible[a]].Title) < strings.ToLower(m.practices[visible[b]].Title)
+		})
+	}
+	return visible
+}
+
+func position(indices []int, i int) int {
+	for pos, idx := range indices {
+		if idx == i {
+			return pos
+		}
+	}
+	return -1
+}
+
+// nextPractice returns the problem after from in the visible list. With
+// wrap set it cycles back to the top, otherwise it stays on the last one.
+func (m model) nextPractice(from int, wrap bool) int {
+	visible := m.visiblePractices()
+	pos := position(visible, from)
+	switch {
+	case pos < 0:
+		return visible[0]
+	case pos < len(visible)-1:
+		return visible[pos+1]
+	case wrap:
+		return visible[0]
+	}
+	return from
+}
+
+func (m model) prevPractice(from int) int {
+	visible := m.visiblePractices()
+	if pos := position(visible, from); pos > 0 {
+		return visible[pos-1]
+	} else if pos < 0 {
+		return visible[0]
+	}
+	return from
+}
+
+// keepPracticeVisible moves the selection to the first visible problem when
+// a filter change hides the current one.
+func (m *model) keepPracticeVisible() {
+	visible := m.visiblePractices()
+	if position(visible, m.practiceIndex) < 0 {
+		m.stashDraft()
+		m.practiceIndex = visible[0]
+		m.err = nil
+		m.startExercise()
+	}
+}
+
+func practiceTags(p models.PracticeProblem) string {
+	tags := make([]string, len(p.Tags))
+	for i, t := range p.Tags {
+		tags[i] = string(t)
+	}
+	return p.Difficulty.String() + " · " + strings.Join(tags, ", ")
+}
+
+// weakSpotsFilter keeps problems tagged with any of the given concepts.
+func weakSpotsFilter(weak []models.Concept) practiceFilter {
+	return practiceFilter{
+		label: "weak spots",
+		keep: func(p models.PracticeProblem) bool {
+			for _, c := range weak {
+				if hasConcept(p, c) {
+					return true
+				}
+			}
+			return false
+		},
+	}
+}
+
+// practiceWeakSpots switches the practice list to the problems covering the
+// learner's weak concepts. It reports false, leaving the list untouched,
+// when there are no weak concepts or no problem covers them.
+func (m *model) practiceWeakSpots(weak []models.Concept) bool {
+	if len(weak) == 0 {
+		return false
+	}
+	filter := weakSpotsFilter(weak)
+	for _, p := range m.practices {
+		if filter.keep(p) {
+			m.practiceFilters = append(practiceFilters(m.practices), filter)
+			m.practiceFilter = len(m.practiceFilters) - 1
+			m.keepPracticeVisible()
+			return true
+		}
+	}
+	return false
+}
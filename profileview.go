@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/ghousemohamed/regex-in-the-terminal/models"
+	"github.com/ghousemohamed/regex-in-the-terminal/storage"
+)
+
+func newProfileInput() textinput.Model {
+	ti := textinput.New()
+	ti.Placeholder = "name for a new profile"
+	ti.Prompt = ""
+	ti.CharLimit = 32
+	return ti
+}
+
+// openProfiles shows the profile picker with the active profile selected.
+func (m model) openProfiles() model {
+	m.profiles, m.err = storage.Profiles()
+	m.profileCursor = 0
+	for i, name := range m.profiles {
+		if name == m.profile {
+			m.profileCursor = i
+		}
+	}
+	m.profileInput = newProfileInput()
+	m.state = models.ChoosingProfile
+	return m
+}
+
+// onNewProfile reports whether the cursor is on the "new profile" entry
+// after the list of existing profiles.
+func (m model) onNewProfile() bool {
+	return m.profileCursor == len(m.profiles)
+}
+
+// switchProfile makes name the active profile and reloads everything from
+// it, returning to the welcome screen.
+func (m model) switchProfile(name string) (model, error) {
+	store, err := storage.Open(name, m.backend)
+	if err != nil {
+		return m, err
+	}
+	m.store.Close()
+	m.store = store
+	m.profile = name
+	newM := resetModel(m)
+	newM.state = models.Welcome
+	return newM, nil
+}
+
+func (m model) updateProfiles(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	// While a new profile name is being typed, letters are part of it.
+	typing := m.onNewProfile() && msg.Type == tea.KeyRunes
+
+	switch {
+	case key.Matches(msg, keys.Back):
+		m.showWelcome()
+		m.err = nil
+		return m, nil
+	case key.Matches(msg, keys.Up) && !typing:
+		if m.profileCursor > 0 {
+			m.profileCursor--
+		}
+	case key.Matches(msg, keys.Down) && !typing:
+		if m.profileCursor < len(m.profiles) {
+			m.profileCursor++
+		}
+	case key.Matches(msg, keys.FreeRoam):
+		m.settings.FreeRoam = !m.settings.FreeRoam
+		m.err = m.store.SaveSettings(m.settings)
+		return m, nil
+	case key.Matches(msg, keys.Select):
+		name := m.profileInput.Value()
+		if !m.onNewProfile() {
+			name = m.profiles[m.profileCursor]
+		}
+		newM, err := m.switchProfile(name)
+		if err != nil {
+			m.err = err
+			return m, nil
+		}
+		return newM, nil
+	}
+
+	if m.onNewProfile() {
+		m.profileInput.Focus()
+		m.profileInput, cmd = m.profileInput.Update(msg)
+	} else {
+		m.profileInput.Blur()
+	}
+	m.err = nil
+	return m, cmd
+}
+
+func (m model) profilesView(header string, totalWidth int) string {
+	var body strings.Builder
+	body.WriteString(gradientText("Profiles") + "\n\n")
+	body.WriteString("Each profile keeps its own progress, settings and history.\n\n")
+
+	for i, name := range m.profiles {
+		cursor := " "
+		style := incompletedStyle
+		if i == m.profileCursor {
+			cursor = ">"
+			style = selectedStyle
+		}
+		label := name
+		if name == m.profile {
+			label += " (active)"
+		}
+		body.WriteString(fmt.Sprintf("%s %s\n", cursor, style.Render(label)))
+	}
+
+	cursor := " "
+	if m.onNewProfile() {
+		cursor = ">"
+	}
+	body.WriteString(fmt.Sprintf("%s + %s\n", cursor, m.profileInput.View()))
+
+	freeRoam := "off"
+	if m.settings.FreeRoam {
+		freeRoam = "on"
+	}
+	body.WriteString(fmt.Sprintf("\nFree roam for %s: %s\n", m.profile, freeRoam))
+
+	if m.err != nil {
+		body.WriteString("\n" + errorStyle.Render(m.err.Error()) + "\n")
+	}
+
+	body.WriteString("\n" + footer(describe(keys.Up, "up"), describe(keys.Down, "down"), describe(keys.Select, "switch"), describe(keys.FreeRoam, "toggle free roam"), describe(keys.Back, "back")))
+
+	return lipgloss.JoinVertical(lipgloss.Center,
+		header,
+		lipgloss.NewStyle().
+			Width(totalWidth-4).
+			Padding(1).
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(borderColor).
+			Render(body.String()),
+	)
+}
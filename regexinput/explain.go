@@ -0,0 +1,120 @@
+package regexinput
+
+import (
+	"regexp/syntax"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// CompileError is a pattern that doesn't compile, explained for someone
+// learning regular expressions.
+type CompileError struct {
+	Pattern string
+	// Start and End are the byte range of Pattern the error is about.
+	Start, End  int
+	Explanation string
+	Err         *syntax.Error
+}
+
+// Error is the explanation, then the pattern with a caret under the
+// offending part.
+func (e *CompileError) Error() string {
+	caret := strings.Repeat(" ", ansi.StringWidth(e.Pattern[:e.Start])) + "^"
+	if rest := ansi.StringWidth(e.Pattern[e.Start:e.End]) - 1; rest > 0 {
+		caret += strings.Repeat("~", rest)
+	}
+	return e.Explanation + "\n\n  " + e.Pattern + "\n  " + caret
+}
+
+func (e *CompileError) Unwrap() error {
+	return e.Err
+}
+
+// unsupported are constructs other regex flavors have and RE2 leaves out,
+// since it guarantees matching in time linear in the input.
+var unsupported = []struct {
+	prefixes    []string
+	explanation string
+}{
+	{[]string{"(?=", "(?!"}, "Lookahead like (?=...) and (?!...) works in PCRE, JavaScript and Python, but Go's RE2 engine doesn't support it. Match the text that follows as well, and use a group to capture just the part you want."},
+	{[]string{"(?<=", "(?<!"}, "Lookbehind like (?<=...) and (?<!...) works in PCRE, JavaScript and Python, but Go's RE2 engine doesn't support it. Match the text that comes before as well, and use a group to capture just the part you want."},
+	{[]string{"(?>"}, "Atomic groups (?>...) work in PCRE and Java, but Go's RE2 engine doesn't support them. It never backtracks, so a plain group (?:...) does the job."},
+	{[]string{"(?#"}, "Comments (?#...) work in PCRE and Python, but Go's RE2 engine doesn't support them. Leave the comment out."},
+	{[]string{`\1`, `\2`, `\3`, `\4`, `\5`, `\6`, `\7`, `\8`, `\9`, `\k`, `(?P=`}, "Backreferences like \\1 work in PCRE, JavaScript and Python, but Go's RE2 engine doesn't support them. Repeat the group's pattern instead, if any text it matches will do."},
+}
+
+// possessive quantifiers end in a + after another quantifier, as in a++ or
+// \d{2}+.
+var possessive = []string{"++", "*+", "?+", "}+"}
+
+var explanations = map[syntax.ErrorCode]string{
+	syntax.ErrMissingParen:          "This group is never closed. Add a ) to end it, or write \\( to match a parenthesis.",
+	syntax.ErrUnexpectedParen:       "This ) doesn't close any group. Remove it, or write \\) to match a parenthesis.",
+	syntax.ErrMissingBracket:        "This character class is never closed. Add a ] to end it, or write \\[ to match a bracket.",
+	syntax.ErrInvalidCharRange:      "This range is backwards. In a range like a-z the first character has to come before the second.",
+	syntax.ErrInvalidCharClass:      "This isn't a character class RE2 knows. Named classes look like [[:alpha:]], \\pL or \\p{Greek}.",
+	syntax.ErrInvalidEscape:         "This backslash escape means nothing here. Escape punctuation, like \\. or \\*, or use a class such as \\d, \\w or \\s.",
+	syntax.ErrTrailingBackslash:     "The pattern ends with a lone backslash. Add the character it should escape, or write \\\\ to match a backslash.",
+	syntax.ErrMissingRepeatArgument: "This quantifier has nothing before it to repeat. Put it after a character or group, or escape it, like \\* or \\+, to match it.",
+	syntax.ErrInvalidRepeatOp:       "These quantifiers follow each other. Use just one, or put the first in a group, like (a+)*.",
+	syntax.ErrInvalidRepeatSize:     "This repeat count doesn't work. In {n,m} n can't be more than m, and neither can be over 1000.",
+	syntax.ErrInvalidNamedCapture:   "This group name isn't valid. Name a group like (?P<name>...), using letters, digits and underscores.",
+	syntax.ErrInvalidPerlOp:         "RE2 doesn't know this kind of group. It has (?:...) for groups that don't capture, (?P<name>...) for named groups, and flags like (?i).",
+	syntax.ErrInvalidUTF8:           "The pattern isn't valid UTF-8 text.",
+	syntax.ErrNestingDepth:          "The groups and quantifiers are nested too deeply. Try a simpler pattern.",
+	syntax.ErrLarge:                 "This pattern is too large for RE2, usually because of big repeat counts. Try smaller ones.",
+}
+
+// posixExplanations replace explanations that suggest Perl syntax when the
+// pattern is parsed as POSIX.
+var posixExplanations = map[syntax.ErrorCode]string{
+	syntax.ErrInvalidEscape: "This backslash escape means nothing in POSIX syntax, which has no \\d, \\w, \\s or \\b. Use a class such as [0-9] or [[:space:]], or escape punctuation, like \\. or \\*.",
+}
+
+// Explain returns why pattern doesn't compile when parsed with flags, as
+// for ErrorSpan, or nil if it does.
+func Explain(pattern string, flags syntax.Flags) *CompileError {
+	start, end, err := ErrorSpan(pattern, flags)
+	if err == nil {
+		return nil
+	}
+	e := &CompileError{Pattern: pattern, Start: start, End: end, Err: err}
+
+	if p, ok := unsupportedPrefix(pattern[start:], flags); ok {
+		for _, u := range unsupported {
+			if slices.Contains(u.prefixes, p) {
+				e.Explanation = u.explanation
+				return e
+			}
+		}
+	}
+	if err.Code == syntax.ErrInvalidRepeatOp {
+		for _, p := range possessive {
+			if strings.HasSuffix(err.Expr, p) {
+				e.Explanation = "Possessive quantifiers like a++ work in PCRE and Java, but Go's RE2 engine doesn't support them. It never backtracks anyway, so drop the last +."
+				return e
+			}
+		}
+	}
+
+	if flags&syntax.PerlX == 0 && err.Code == syntax.ErrMissingRepeatArgument && start > 0 && pattern[start-1] == '(' && strings.HasPrefix(err.Expr, "?") {
+		e.Explanation = "POSIX syntax has no (?...) groups or inline flags like (?i). Use a plain (...) group instead."
+		return e
+	}
+
+	code := err.Code
+	if code == syntax.ErrInvalidCharRange && (strings.HasPrefix(err.Expr, "[:") || strings.HasPrefix(err.Expr, `\p`) || strings.HasPrefix(err.Expr, `\P`)) {
+		// regexp/syntax reports unknown class names as bad ranges.
+		code = syntax.ErrInvalidCharClass
+	}
+	e.Explanation = explanations[code]
+	if flags&syntax.PerlX == 0 && posixExplanations[code] != "" {
+		e.Explanation = posixExplanations[code]
+	}
+	if e.Explanation == "" {
+		e.Explanation = err.Error()
+	}
+	return e
+}
@@ -0,0 +1,64 @@
+package regexinput
+
+import (
+	"regexp/syntax"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	tests := []struct {
+		name       string
+		pattern    string
+		flags      syntax.Flags
+		start, end int
+		want       string
+	}{
+		{"lookbehind", "a(?<=b)c", syntax.Perl, 1, 5, unsupported[1].explanation},
+		{"lookahead", "a(?=b)", syntax.Perl, 1, 4, unsupported[0].explanation},
+		{"numbered backreference", `(a)b\1`, syntax.Perl, 4, 6, unsupported[4].explanation},
+		{"named backreference", `(?P<n>a)(?P=n)`, syntax.Perl, 8, 14, unsupported[4].explanation},
+		{"k backreference", `(?<n>a)\k<n>b`, syntax.Perl, 7, 12, unsupported[4].explanation},
+		{"k backreference in braces", `(a)\k{n}`, syntax.Perl, 3, 8, unsupported[4].explanation},
+		{"backreference in posix", `(a)\1`, syntax.POSIX, 3, 5, unsupported[4].explanation},
+		{"recursion after a named group", `(?P<n>a)(?P>n)`, syntax.Perl, 8, 11, explanations[syntax.ErrInvalidPerlOp]},
+		{"possessive", "ab++", syntax.Perl, 2, 4, "Possessive quantifiers like a++ work in PCRE and Java, but Go's RE2 engine doesn't support them. It never backtracks anyway, so drop the last +."},
+		{"unclosed group", "x(ab", syntax.Perl, 1, 4, explanations[syntax.ErrMissingParen]},
+		{"stray paren", "ab)c", syntax.Perl, 2, 3, explanations[syntax.ErrUnexpectedParen]},
+		{"unclosed class", "a[bc", syntax.Perl, 1, 4, explanations[syntax.ErrMissingBracket]},
+		{"backwards range", "[z-a]", syntax.Perl, 1, 4, explanations[syntax.ErrInvalidCharRange]},
+		{"unknown class name", "[[:vowel:]]", syntax.Perl, 1, 10, explanations[syntax.ErrInvalidCharClass]},
+		{"perl escape", `a\q`, syntax.Perl, 1, 3, explanations[syntax.ErrInvalidEscape]},
+		{"posix escape", `\d+`, syntax.POSIX, 0, 2, posixExplanations[syntax.ErrInvalidEscape]},
+		{"posix flag group", "(?i)abc", syntax.POSIX, 1, 2, "POSIX syntax has no (?...) groups or inline flags like (?i). Use a plain (...) group instead."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := Explain(tt.pattern, tt.flags)
+			if e == nil {
+				t.Fatalf("Explain(%q) = nil, want an error", tt.pattern)
+			}
+			if e.Start != tt.start || e.End != tt.end {
+				t.Errorf("span = %d..%d, want %d..%d", e.Start, e.End, tt.start, tt.end)
+			}
+			if e.Explanation != tt.want {
+				t.Errorf("explanation = %q, want %q", e.Explanation, tt.want)
+			}
+		})
+	}
+}
+
+func TestExplainValid(t *testing.T) {
+	for _, tt := range []struct {
+		pattern string
+		flags   syntax.Flags
+	}{
+		{`\d+(?:ab)?`, syntax.Perl},
+		{"(?i)abc", syntax.Perl},
+		{"[[:digit:]]+(a|b)", syntax.POSIX},
+	} {
+		if e := Explain(tt.pattern, tt.flags); e != nil {
+			t.Errorf("Explain(%q) = %q, want nil", tt.pattern, e.Explanation)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package regexinput
+
+import (
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxUndo is how many edits Undo can go back.
+const maxUndo = 100
+
+// edit is the input as it was before or after an edit.
+type edit struct {
+	value  string
+	cursor int
+}
+
+// history is the state for recalling earlier patterns and undoing edits.
+type history struct {
+	// patterns were submitted before, oldest first and without repeats.
+	patterns []string
+	// recalled is the index in patterns on screen, or len(patterns) for the
+	// pattern being written, which is kept in draft meanwhile.
+	recalled int
+	draft    string
+
+	undo, redo []edit
+	// typing is set while the last edit was typing at the cursor, so that
+	// a run of typed characters is undone as one.
+	typing bool
+}
+
+func (m Model) current() edit {
+	return edit{m.Value(), m.Position()}
+}
+
+func (m *Model) restore(e edit) {
+	m.Model.SetValue(e.value)
+	m.Model.SetCursor(e.cursor)
+	m.history.typing = false
+}
+
+// pushUndo records e as the state to go back to, and forgets what was
+// undone, as a new edit makes it unreachable.
+func (m *Model) pushUndo(e edit) {
+	m.history.undo = append(m.history.undo, e)
+	if n := len(m.history.undo); n > maxUndo {
+		m.history.undo = m.history.undo[n-maxUndo:]
+	}
+	m.history.redo = nil
+}
+
+// SetValue sets the pattern as an edit that can be undone.
+func (m *Model) SetValue(s string) {
+	if s != m.Value() {
+		m.pushUndo(m.current())
+	}
+	m.history.typing = false
+	m.Model.SetValue(s)
+}
+
+// Reset sets the pattern for a new exercise, with patterns as those
+// submitted for it before, oldest first. Edits made until now can no
+// longer be undone.
+func (m *Model) Reset(value string, patterns []string) {
+	m.history = history{}
+	for _, p := range patterns {
+		m.remember(p)
+	}
+	m.history.recalled = len(m.history.patterns)
+	m.offset = 0
+	m.Model.SetValue(value)
+}
+
+// remember adds p as the latest submitted pattern.
+func (m *Model) remember(p string) {
+	if p == "" {
+		return
+	}
+	m.history.patterns = slices.DeleteFunc(m.history.patterns, func(q string) bool { return q == p })
+	m.history.patterns = append(m.history.patterns, p)
+}
+
+// Submitted adds p to the patterns that can be recalled, and goes back to
+// the pattern being written.
+func (m *Model) Submitted(p string) {
+	m.remember(p)
+	m.history.recalled = len(m.history.patterns)
+}
+
+// Recall replaces the pattern with an earlier submitted one when delta is
+// negative, or a later one when positive. Going past the latest brings back
+// what was being written before recalling.
+func (m *Model) Recall(delta int) {
+	h := &m.history
+	i := min(max(h.recalled+delta, 0), len(h.patterns))
+	if i == h.recalled {
+		return
+	}
+	if h.recalled == len(h.patterns) {
+		h.draft = m.Value()
+	}
+	h.recalled = i
+
+	value := h.draft
+	if i < len(h.patterns) {
+		value = h.patterns[i]
+	}
+	m.SetValue(value)
+	m.CursorEnd()
+}
+
+// Undo takes back the last edit.
+func (m *Model) Undo() {
+	h := &m.history
+	if len(h.undo) == 0 {
+		return
+	}
+	h.redo = append(h.redo, m.current())
+	last := h.undo[len(h.undo)-1]
+	h.undo = h.undo[:len(h.undo)-1]
+	m.restore(last)
+}
+
+// Redo makes the last edit undone again.
+func (m *Model) Redo() {
+	h := &m.history
+	if len(h.redo) == 0 {
+		return
+	}
+	h.undo = append(h.undo, m.current())
+	last := h.redo[len(h.redo)-1]
+	h.redo = h.redo[:len(h.redo)-1]
+	m.restore(last)
+}
+
+// trackEdit records the change msg made from before for Undo.
+func (m *Model) trackEdit(msg tea.Msg, before edit) {
+	if m.Value() == before.value {
+		if m.Position() != before.cursor {
+			m.history.typing = false
+		}
+		return
+	}
+	keyMsg, ok := msg.(tea.KeyMsg)
+	typing := ok && keyMsg.Type == tea.KeyRunes && !keyMsg.Paste
+	if !typing || !m.history.typing {
+		m.pushUndo(before)
+	} else {
+		m.history.redo = nil
+	}
+	m.history.typing = typing
+}
@@ -0,0 +1,190 @@
+// Package regexinput is a text input for regular expressions that colors
+// the pattern by syntax as it is typed.
+package regexinput
+
+import (
+	"regexp/syntax"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Styles are how each kind of token is drawn.
+type Styles struct {
+	Literal, Escape, Meta, Class, Quantifier, Group, Anchor lipgloss.Style
+	// MatchingParen is drawn over the parenthesis under the cursor and
+	// the one it pairs with.
+	MatchingParen lipgloss.Style
+	// Invalid is drawn over the part of the pattern that doesn't parse.
+	Invalid lipgloss.Style
+}
+
+// DefaultStyles underline invalid regions and otherwise only rely on bold
+// and reverse, for use before a theme is chosen.
+func DefaultStyles() Styles {
+	return Styles{
+		Meta:          lipgloss.NewStyle().Bold(true),
+		Quantifier:    lipgloss.NewStyle().Bold(true),
+		Group:         lipgloss.NewStyle().Bold(true),
+		Anchor:        lipgloss.NewStyle().Bold(true),
+		MatchingParen: lipgloss.NewStyle().Reverse(true),
+		Invalid:       lipgloss.NewStyle().Underline(true),
+	}
+}
+
+// Model is a textinput.Model with its own View, undo and redo, and recall
+// of earlier patterns. Editing, the cursor and the placeholder are all the
+// embedded model's.
+type Model struct {
+	textinput.Model
+	Styles Styles
+	// Flags are what the pattern is parsed with to find invalid parts, as
+	// for ErrorSpan.
+	Flags syntax.Flags
+
+	history history
+	// offset is the first rune shown when the pattern is wider than
+	// Width.
+	offset int
+}
+
+// New returns a Model with DefaultStyles that parses patterns as Perl
+// syntax.
+func New() Model {
+	return Model{Model: textinput.New(), Styles: DefaultStyles(), Flags: syntax.Perl}
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	before := m.current()
+	var cmd tea.Cmd
+	m.Model, cmd = m.Model.Update(msg)
+	m.trackEdit(msg, before)
+	m.offset, _ = m.window([]rune(m.Value()))
+	return m, cmd
+}
+
+// window returns the runes of value shown, from start up to end: as many
+// as fit in Width, scrolled no further from offset than it takes to keep
+// the cursor in view. As with textinput, the cursor after the last rune
+// takes a cell of its own beyond Width.
+func (m Model) window(value []rune) (start, end int) {
+	width := func(runes []rune) int {
+		return ansi.StringWidth(string(runes))
+	}
+	if m.Width <= 0 || width(value) <= m.Width {
+		return 0, len(value)
+	}
+
+	cursor := min(m.Position(), len(value))
+	start = min(m.offset, cursor)
+	for start < cursor && width(value[start:min(cursor+1, len(value))]) > m.Width {
+		start++
+	}
+	end = start
+	for end < len(value) && width(value[start:end+1]) <= m.Width {
+		end++
+	}
+	// Don't leave room at the end while text is hidden at the start.
+	for end == len(value) && start > 0 && width(value[start-1:end]) <= m.Width {
+		start--
+	}
+	return start, end
+}
+
+func (m Model) style(kind Kind) lipgloss.Style {
+	switch kind {
+	case Escape:
+		return m.Styles.Escape
+	case Meta:
+		return m.Styles.Meta
+	case Class:
+		return m.Styles.Class
+	case Quantifier:
+		return m.Styles.Quantifier
+	case Group:
+		return m.Styles.Group
+	case Anchor:
+		return m.Styles.Anchor
+	}
+	return m.Styles.Literal
+}
+
+// View draws the prompt and the part of the colored pattern that fits in
+// Width, with the cursor.
+func (m Model) View() string {
+	value := m.Value()
+	if value == "" {
+		return m.Model.View()
+	}
+
+	tokens := Tokenize(value)
+	invalid := Explain(value, m.Flags)
+
+	// Byte offset of the cursor, which Position counts in runes.
+	cursor := len(value)
+	if pos := m.Position(); pos < utf8.RuneCountInString(value) {
+		cursor = len(string([]rune(value)[:pos]))
+	}
+
+	// The parentheses to pair up: the one under the cursor, or else the
+	// one just before it, as a closing parenthesis has usually just been
+	// typed.
+	paired := map[int]bool{}
+	for _, at := range []int{cursor, cursor - 1} {
+		if i := tokenAt(tokens, at); i >= 0 && tokens[i].Kind == Group && tokens[i].Partner >= 0 {
+			paired[i] = true
+			paired[tokens[i].Partner] = true
+			break
+		}
+	}
+
+	first, last := m.window([]rune(value))
+	n := 0 // runes so far
+
+	var b strings.Builder
+	b.WriteString(m.PromptStyle.Render(m.Prompt))
+	for i, t := range tokens {
+		style := m.style(t.Kind)
+		if paired[i] {
+			style = m.Styles.MatchingParen.Inherit(style)
+		}
+		for j, r := range t.Text(value) {
+			n++
+			if n <= first || n > last {
+				continue
+			}
+			at := t.Start + j
+			s := style
+			if invalid != nil && at >= invalid.Start && at < invalid.End {
+				s = m.Styles.Invalid.Inherit(s)
+			}
+			if at == cursor && m.Focused() {
+				m.Cursor.TextStyle = s
+				m.Cursor.SetChar(string(r))
+				b.WriteString(m.Cursor.View())
+				continue
+			}
+			b.WriteString(s.Render(string(r)))
+		}
+	}
+	if cursor == len(value) && m.Focused() {
+		m.Cursor.TextStyle = m.TextStyle
+		m.Cursor.SetChar(" ")
+		b.WriteString(m.Cursor.View())
+	}
+	return b.String()
+}
+
+// tokenAt returns the index of the token covering byte offset at, or -1.
+func tokenAt(tokens []Token, at int) int {
+	for i, t := range tokens {
+		if at >= t.Start && at < t.End {
+			return i
+		}
+	}
+	return -1
+}
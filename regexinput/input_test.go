@@ -0,0 +1,60 @@
+package regexinput
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestViewScrollsWithinWidth(t *testing.T) {
+	m := New()
+	m.Prompt = "> "
+	m.Width = 10
+	m.Focus()
+
+	pattern := `^(\d{3})-(\d{4})-([a-z]+)$`
+	for _, r := range pattern {
+		m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+
+	steps := []struct {
+		name string
+		keys []tea.KeyType
+		// start and end are the runes that should be shown.
+		start, end int
+	}{
+		{"typed to the end", nil, len(pattern) - 10, len(pattern)},
+		{"to the start", []tea.KeyType{tea.KeyHome}, 0, 10},
+		{"right within the window", []tea.KeyType{tea.KeyRight, tea.KeyRight}, 0, 10},
+		{"to the end again", []tea.KeyType{tea.KeyEnd}, len(pattern) - 10, len(pattern)},
+		{"left within the window", []tea.KeyType{tea.KeyLeft, tea.KeyLeft}, len(pattern) - 10, len(pattern)},
+	}
+	for _, step := range steps {
+		for _, k := range step.keys {
+			m, _ = m.Update(tea.KeyMsg{Type: k})
+		}
+		start, end := m.window([]rune(m.Value()))
+		if start != step.start || end != step.end {
+			t.Errorf("%s: window = %d..%d, want %d..%d", step.name, start, end, step.start, step.end)
+		}
+		// The prompt, Width runes, and the cursor after the last one.
+		view := m.View()
+		if w := lipgloss.Width(view); w > len(m.Prompt)+m.Width+1 {
+			t.Errorf("%s: view is %d wide, want at most %d", step.name, w, len(m.Prompt)+m.Width+1)
+		}
+		if strings.Contains(view, "\n") {
+			t.Errorf("%s: view wraps", step.name)
+		}
+	}
+}
+
+func TestViewFits(t *testing.T) {
+	m := New()
+	m.Width = 20
+	m.SetValue("ab(c)")
+	if start, end := m.window([]rune(m.Value())); start != 0 || end != 5 {
+		t.Errorf("window = %d..%d, want the whole pattern", start, end)
+	}
+}
@@ -0,0 +1,340 @@
+package regexinput
+
+import (
+	"errors"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"unicode/utf8"
+)
+
+// Kind is what a piece of a pattern does.
+type Kind int
+
+const (
+	Literal Kind = iota
+	// Escape is an escaped literal such as \. or \x41.
+	Escape
+	// Meta is . and |.
+	Meta
+	// Class is a character class: [a-z], \d, \pL and the like.
+	Class
+	// Quantifier is *, +, ?, {n,m} and their lazy forms.
+	Quantifier
+	// Group is an opening or closing parenthesis, with any (?: or (?P<name>
+	// prefix, or a flag group such as (?i).
+	Group
+	// Anchor is ^, $, \b, \B, \A and \z.
+	Anchor
+)
+
+// Token is a piece of a pattern, as a byte range.
+type Token struct {
+	Kind       Kind
+	Start, End int
+	// Partner is the index of the matching parenthesis of a Group token,
+	// or -1 when it has none.
+	Partner int
+}
+
+// Text returns the part of pattern that t covers.
+func (t Token) Text(pattern string) string {
+	return pattern[t.Start:t.End]
+}
+
+var repeatPattern = regexp.MustCompile(`^\{\d+(,\d*)?\}\??`)
+
+// Tokenize splits pattern into tokens following the RE2 syntax accepted by
+// regexp/syntax in Perl mode. It never fails: whatever doesn't make sense
+// is left for syntax.Parse to report.
+func Tokenize(pattern string) []Token {
+	var tokens []Token
+	var open []int // indexes of the unclosed opening parentheses
+
+	add := func(kind Kind, start, end int) {
+		tokens = append(tokens, Token{Kind: kind, Start: start, End: end, Partner: -1})
+	}
+
+	for i := 0; i < len(pattern); {
+		c := pattern[i]
+		switch c {
+		case '\\':
+			end := escapeEnd(pattern, i)
+			add(escapeKind(pattern[i:end]), i, end)
+			i = end
+		case '[':
+			end := classEnd(pattern, i)
+			add(Class, i, end)
+			i = end
+		case '(':
+			end := groupPrefixEnd(pattern, i)
+			if end > i+2 && pattern[end-1] == ')' {
+				// A flag group such as (?i) has nothing to match up.
+				add(Group, i, end)
+			} else {
+				open = append(open, len(tokens))
+				add(Group, i, end)
+			}
+			i = end
+		case ')':
+			add(Group, i, i+1)
+			if n := len(open); n > 0 {
+				tokens[open[n-1]].Partner = len(tokens) - 1
+				tokens[len(tokens)-1].Partner = open[n-1]
+				open = open[:n-1]
+			}
+			i++
+		case '*', '+', '?':
+			end := i + 1
+			if end < len(pattern) && pattern[end] == '?' {
+				end++
+			}
+			add(Quantifier, i, end)
+			i = end
+		case '{':
+			if loc := repeatPattern.FindStringIndex(pattern[i:]); loc != nil {
+				add(Quantifier, i, i+loc[1])
+				i += loc[1]
+			} else {
+				add(Literal, i, i+1)
+				i++
+			}
+		case '^', '$':
+			add(Anchor, i, i+1)
+			i++
+		case '.', '|':
+			add(Meta, i, i+1)
+			i++
+		default:
+			_, size := utf8.DecodeRuneInString(pattern[i:])
+			add(Literal, i, i+size)
+			i += size
+		}
+	}
+	return tokens
+}
+
+// escapeEnd returns where the escape sequence starting at i ends.
+func escapeEnd(pattern string, i int) int {
+	if i+1 >= len(pattern) {
+		return len(pattern)
+	}
+	switch pattern[i+1] {
+	case 'p', 'P':
+		if i+2 < len(pattern) && pattern[i+2] == '{' {
+			if j := strings.IndexByte(pattern[i:], '}'); j >= 0 {
+				return i + j + 1
+			}
+			return len(pattern)
+		}
+		return min(i+3, len(pattern))
+	case 'x':
+		if i+2 < len(pattern) && pattern[i+2] == '{' {
+			if j := strings.IndexByte(pattern[i:], '}'); j >= 0 {
+				return i + j + 1
+			}
+			return len(pattern)
+		}
+		return min(i+4, len(pattern))
+	case 'Q':
+		if j := strings.Index(pattern[i+2:], `\E`); j >= 0 {
+			return i + 2 + j + 2
+		}
+		return len(pattern)
+	}
+	_, size := utf8.DecodeRuneInString(pattern[i+1:])
+	return i + 1 + size
+}
+
+func escapeKind(escape string) Kind {
+	if len(escape) < 2 {
+		return Escape
+	}
+	switch escape[1] {
+	case 'd', 'D', 'w', 'W', 's', 'S', 'p', 'P':
+		return Class
+	case 'b', 'B', 'A', 'z':
+		return Anchor
+	}
+	return Escape
+}
+
+// classEnd returns where the bracketed class starting at i ends, or the end
+// of the pattern if it is never closed.
+func classEnd(pattern string, i int) int {
+	j := i + 1
+	if j < len(pattern) && pattern[j] == '^' {
+		j++
+	}
+	// A ] straight after the opening bracket is a literal.
+	if j < len(pattern) && pattern[j] == ']' {
+		j++
+	}
+	for j < len(pattern) {
+		switch {
+		case pattern[j] == '\\':
+			j = escapeEnd(pattern, j)
+		case strings.HasPrefix(pattern[j:], "[:"):
+			if k := strings.Index(pattern[j+2:], ":]"); k >= 0 {
+				j += 2 + k + 2
+			} else {
+				j++
+			}
+		case pattern[j] == ']':
+			return j + 1
+		default:
+			j++
+		}
+	}
+	return len(pattern)
+}
+
+// groupPrefixEnd returns where the opening of the group at i ends: after
+// the parenthesis, after (?: or (?P<name>, or after a whole flag group.
+// Unsupported openings such as (?= end at their first unexpected byte.
+func groupPrefixEnd(pattern string, i int) int {
+	if i+1 >= len(pattern) || pattern[i+1] != '?' {
+		return i + 1
+	}
+	for j := i + 2; j < len(pattern); j++ {
+		switch pattern[j] {
+		case 'i', 'm', 's', 'U', '-', 'P':
+		case '<':
+			if j+1 < len(pattern) && (pattern[j+1] == '=' || pattern[j+1] == '!') {
+				return j + 2 // lookbehind
+			}
+			if k := strings.IndexByte(pattern[j:], '>'); k >= 0 {
+				return j + k + 1
+			}
+			return len(pattern)
+		default:
+			return j + 1
+		}
+	}
+	return len(pattern)
+}
+
+// ErrorSpan parses pattern with flags, syntax.Perl as regexp.Compile uses
+// or syntax.POSIX as regexp.CompilePOSIX does, and, if it is invalid,
+// returns the syntax error and the byte range of pattern it is about.
+//
+// regexp/syntax doesn't report positions, so the range is worked out from
+// the error: for parentheses and brackets from the tokens, for constructs
+// of other flavors from their own prefixes, and otherwise by finding where
+// the error's expression makes the pattern fail.
+func ErrorSpan(pattern string, flags syntax.Flags) (start, end int, err *syntax.Error) {
+	_, parseErr := syntax.Parse(pattern, flags)
+	if !errors.As(parseErr, &err) {
+		return 0, 0, nil
+	}
+
+	tokens := Tokenize(pattern)
+	if start, end, ok := unsupportedSpan(pattern, flags, tokens); ok {
+		return start, end, err
+	}
+	switch err.Code {
+	case syntax.ErrMissingParen:
+		for _, t := range tokens {
+			if pattern[t.Start] == '(' && t.Partner < 0 && !isFlagGroup(pattern[t.Start:t.End]) {
+				return t.Start, len(pattern), err
+			}
+		}
+	case syntax.ErrUnexpectedParen:
+		for _, t := range tokens {
+			if pattern[t.Start] == ')' && t.Partner < 0 {
+				return t.Start, t.End, err
+			}
+		}
+	case syntax.ErrMissingBracket:
+		for _, t := range tokens {
+			if t.Kind == Class && pattern[t.Start] == '[' && t.End == len(pattern) {
+				return t.Start, t.End, err
+			}
+		}
+	}
+
+	if err.Expr != "" {
+		// The parser stops at the first fault, so the fault is the last
+		// place the expression appears with nothing wrong before it.
+		found := -1
+		for i := strings.Index(pattern, err.Expr); i >= 0; {
+			_, prefixErr := syntax.Parse(pattern[:i+len(err.Expr)], flags)
+			var e *syntax.Error
+			if errors.As(prefixErr, &e) && e.Code == err.Code && parsesUpTo(pattern[:i], flags) {
+				found = i
+			}
+			next := strings.Index(pattern[i+1:], err.Expr)
+			if next < 0 {
+				break
+			}
+			i += 1 + next
+		}
+		if found < 0 {
+			found = strings.LastIndex(pattern, err.Expr)
+		}
+		if found >= 0 {
+			return found, found + len(err.Expr), err
+		}
+	}
+	return 0, len(pattern), err
+}
+
+// unsupportedSpan finds the first construct of another regex flavor that
+// RE2 leaves out, such as (?P=name) or \k<name>, with nothing wrong before
+// it but groups still open, so that it is what the parser stopped at. Only
+// constructs that start a token count, which leaves out escaped text like
+// \(?=.
+func unsupportedSpan(pattern string, flags syntax.Flags, tokens []Token) (start, end int, ok bool) {
+	for _, t := range tokens {
+		p, ok := unsupportedPrefix(pattern[t.Start:], flags)
+		if !ok {
+			continue
+		}
+		if !parsesUpTo(pattern[:t.Start], flags) {
+			return 0, 0, false
+		}
+
+		end := t.Start + len(p)
+		// A named backreference runs to the end of its name: (?P=name),
+		// \k<name>, \k{name} or \k'name'.
+		close, named := byte(')'), p == "(?P="
+		if p == `\k` && end < len(pattern) {
+			close, named = nameClosers[pattern[end]]
+		}
+		if named {
+			if i := strings.IndexByte(pattern[end:], close); i > 0 {
+				end += i + 1
+			}
+		}
+		return t.Start, end, true
+	}
+	return 0, 0, false
+}
+
+var nameClosers = map[byte]byte{'<': '>', '{': '}', '\'': '\''}
+
+// parsesUpTo reports whether prefix has no fault but groups still open,
+// so that a pattern starting with it goes wrong only later.
+func parsesUpTo(prefix string, flags syntax.Flags) bool {
+	_, err := syntax.Parse(prefix, flags)
+	var e *syntax.Error
+	return err == nil || errors.As(err, &e) && e.Code == syntax.ErrMissingParen
+}
+
+// unsupportedPrefix returns the prefix of an unsupported construct that s
+// starts with. Groups only count in Perl syntax; POSIX syntax has no (?...)
+// groups at all.
+func unsupportedPrefix(s string, flags syntax.Flags) (string, bool) {
+	for _, u := range unsupported {
+		for _, p := range u.prefixes {
+			if strings.HasPrefix(s, p) && (p[0] != '(' || flags&syntax.PerlX != 0) {
+				return p, true
+			}
+		}
+	}
+	return "", false
+}
+
+func isFlagGroup(s string) bool {
+	return len(s) > 2 && s[1] == '?' && s[len(s)-1] == ')'
+}
@@ -0,0 +1,42 @@
+package regexinput
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    []Token
+	}{
+		{"", nil},
+		{`a.\.`, []Token{
+			{Literal, 0, 1, -1}, {Meta, 1, 2, -1}, {Escape, 2, 4, -1},
+		}},
+		{`^\d{2,3}?$`, []Token{
+			{Anchor, 0, 1, -1}, {Class, 1, 3, -1}, {Quantifier, 3, 9, -1}, {Anchor, 9, 10, -1},
+		}},
+		{"(?:a(b))+", []Token{
+			{Group, 0, 3, 5}, {Literal, 3, 4, -1}, {Group, 4, 5, 4}, {Literal, 5, 6, -1},
+			{Group, 6, 7, 2}, {Group, 7, 8, 0}, {Quantifier, 8, 9, -1},
+		}},
+		{"(?i)[a-z]*?", []Token{
+			{Group, 0, 4, -1}, {Class, 4, 9, -1}, {Quantifier, 9, 11, -1},
+		}},
+		{"(a))", []Token{
+			{Group, 0, 1, 2}, {Literal, 1, 2, -1}, {Group, 2, 3, 0}, {Group, 3, 4, -1},
+		}},
+		{"a{x}é", []Token{
+			{Literal, 0, 1, -1}, {Literal, 1, 2, -1}, {Literal, 2, 3, -1}, {Literal, 3, 4, -1}, {Literal, 4, 6, -1},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			if got := Tokenize(tt.pattern); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Tokenize(%q) =\n%v\nwant\n%v", tt.pattern, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/ghousemohamed/regex-in-the-terminal/achievements"
+	"github.com/ghousemohamed/regex-in-the-terminal/data"
+	"github.com/ghousemohamed/regex-in-the-terminal/models"
+	"github.com/ghousemohamed/regex-in-the-terminal/storage"
+)
+
+type resetAction int
+
+const (
+	resetCurrent resetAction = iota
+	resetAll
+	undoReset
+	cancelReset
+)
+
+type resetOption struct {
+	action resetAction
+	label  string
+}
+
+// resetTrack returns the progress ctrl+r resets on the current screen, as
+// named by storage.ClearSpecificProgress, and the index of the exercise
+// picked in the TOC, or else the one on screen.
+func (m model) resetTrack() (string, int) {
+	if m.state == models.Practicing {
+		if m.tocCursor >= 0 {
+			return "practice", m.tocCursor
+		}
+		return "practice", m.practiceIndex
+	}
+	if m.tocCursor >= 0 && m.state == models.Learning {
+		return "learning", m.tocCursor
+	}
+	return "learning", m.current
+}
+
+// openReset asks what to reset instead of resetting straight away.
+func (m model) openReset() model {
+	m.resetOptions = nil
+	_, i := m.resetTrack()
+	switch m.state {
+	case models.Learning:
+		if m.lessons[i].Completed {
+			m.resetOptions = append(m.resetOptions, resetOption{resetCurrent,
+				fmt.Sprintf("Reset only lesson %d: %s", i+1, m.lessons[i].Title)})
+		}
+		m.resetOptions = append(m.resetOptions, resetOption{resetAll, "Reset all lessons"})
+	case models.Practicing:
+		if m.practices[i].Completed {
+			m.resetOptions = append(m.resetOptions, resetOption{resetCurrent,
+				fmt.Sprintf("Reset only problem %d: %s", i+1, m.practices[i].Title)})
+		}
+		m.resetOptions = append(m.resetOptions, resetOption{resetAll, "Reset all practice problems"})
+	case models.Success:
+		m.resetOptions = append(m.resetOptions, resetOption{resetAll, "Reset all lessons"})
+	default:
+		return m
+	}
+
+	if snapshot, err := storage.LastReset(m.store); err == nil && snapshot != nil {
+		m.resetOptions = append(m.resetOptions, resetOption{undoReset,
+			fmt.Sprintf("Undo the last reset (%s, %s)", snapshot.Description, snapshot.Time.Local().Format("Jan 2 15:04"))})
+	}
+	m.resetOptions = append(m.resetOptions, resetOption{cancelReset, "Cancel"})
+
+	// Start on the least destructive choice.
+	m.resetCursor = len(m.resetOptions) - 1
+	m.confirmingReset = true
+	return m
+}
+
+func (m model) updateReset(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, keys.Up):
+		if m.resetCursor > 0 {
+			m.resetCursor--
+		}
+	case key.Matches(msg, keys.Down):
+		if m.resetCursor < len(m.resetOptions)-1 {
+			m.resetCursor++
+		}
+	case key.Matches(msg, keys.Back):
+		m.confirmingReset = false
+	case key.Matches(msg, keys.Select):
+		m.confirmingReset = false
+		if m.resetOptions[m.resetCursor].action == cancelReset {
+			return m, nil
+		}
+		newM, err := m.applyReset(m.resetOptions[m.resetCursor].action)
+		if err != nil {
+			m.saveErr = err
+			return m, nil
+		}
+		return newM, nil
+	}
+	return m, nil
+}
+
+// applyReset carries out a choice from the reset dialog and reloads the
+// progress it changed.
+func (m model) applyReset(action resetAction) (model, error) {
+	// Keep the drafts, which are not part of the progress being reset.
+	m.noteSaveError(m.saveSession())
+
+	track, index := m.resetTrack()
+	state := m.state
+	var notice string
+
+	switch action {
+	case resetCurrent:
+		title := m.lessons[index].Title
+		if track == "practice" {
+			title = m.practices[index].Title
+		}
+		id := m.lessons[index].ExerciseID()
+		if track == "practice" {
+			id = m.practices[index].ExerciseID()
+		}
+		description := fmt.Sprintf("%q", title)
+		if err := storage.Reset(m.store, track, index, id, description); err != nil {
+			return m, err
+		}
+		notice = fmt.Sprintf("Reset %s. Press %s to undo.", description, keyName(keys.Reset))
+	case resetAll:
+		if err := storage.ClearSpecificProgress(m.store, track); err != nil {
+			return m, err
+		}
+		if state == models.Success {
+			state = models.Learning
+		}
+		notice = fmt.Sprintf("Progress reset. Press %s to undo.", keyName(keys.Reset))
+	case undoReset:
+		snapshot, err := storage.UndoReset(m.store)
+		if errors.Is(err, storage.ErrNothingToUndo) {
+			return m, nil
+		}
+		if err != nil {
+			return m, err
+		}
+		notice = fmt.Sprintf("Restored %s.", snapshot.Description)
+	}
+
+	if action != undoReset {
+		if err := revokeLostBadges(m.store); err != nil {
+			return m, err
+		}
+	}
+
+	newM := resetModel(m)
+	if action == resetCurrent {
+		newM.current = m.current
+		newM.practiceIndex = m.practiceIndex
+	}
+	newM.state = state
+	newM.notice = notice
+	newM.startExercise()
+	return newM, nil
+}
+
+// revokeLostBadges takes away the badges a reset has left unearned, judging
+// them on the progress as stored. UndoReset gives them back.
+func revokeLostBadges(store storage.Store) error {
+	lessons, practices := data.GetLessons(), data.GetPracticeProblems()
+	state, progress, err := badgeState(store, lessons, practices)
+	if err != nil {
+		return err
+	}
+	markCompleted(lessons, practices, progress)
+
+	var ids []string
+	for _, b := range achievements.Lost(state, heldBadges(progress)) {
+		ids = append(ids, b.ID)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	return storage.RevokeBadges(store, ids)
+}
+
+func (m model) resetView(header string, totalWidth int) string {
+	var body strings.Builder
+	body.WriteString(gradientText("Reset Progress") + "\n\n")
+	body.WriteString("What would you like to reset? A reset can be undone from this menu.\n\n")
+
+	for i, option := range m.resetOptions {
+		cursor := " "
+		style := incompletedStyle
+		if i == m.resetCursor {
+			cursor = ">"
+			style = selectedStyle
+		}
+		if option.action == resetAll && i == m.resetCursor {
+			style = errorStyle.Copy().Bold(true)
+		}
+		body.WriteString(fmt.Sprintf("%s %s\n", cursor, style.Render(option.label)))
+	}
+
+	body.WriteString("\n" + footer(describe(keys.Up, "up"), describe(keys.Down, "down"), describe(keys.Select, "confirm"), describe(keys.Back, "cancel")))
+
+	return lipgloss.JoinVertical(lipgloss.Center,
+		header,
+		lipgloss.NewStyle().
+			Width(totalWidth-4).
+			Padding(1).
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(borderColor).
+			Render(body.String()),
+	)
+}
@@ -0,0 +1,89 @@
+package review
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/ghousemohamed/regex-in-the-terminal/models"
+)
+
+const (
+	initialEase = 2.5
+	minimumEase = 1.3
+)
+
+// Quality grades a solved exercise on the SM-2 scale of 0 to 5 from how
+// many attempts it needed and how long it took. Anything below 3 counts as
+// a lapse and restarts the schedule.
+func Quality(elapsed time.Duration, attempts int) int {
+	switch {
+	case attempts <= 1 && elapsed < 2*time.Minute:
+		return 5
+	case attempts <= 1:
+		return 4
+	case attempts <= 3:
+		return 3
+	case attempts <= 6:
+		return 2
+	}
+	return 1
+}
+
+// Schedule applies one SM-2 repetition with the given quality to item and
+// returns the updated item, due interval days after now.
+func Schedule(item models.ReviewItem, quality int, now time.Time) models.ReviewItem {
+	if item.EaseFactor == 0 {
+		item.EaseFactor = initialEase
+	}
+
+	if quality < 3 {
+		item.Repetitions = 0
+		item.Interval = 1
+	} else {
+		switch item.Repetitions {
+		case 0:
+			item.Interval = 1
+		case 1:
+			item.Interval = 6
+		default:
+			item.Interval = int(math.Round(float64(item.Interval) * item.EaseFactor))
+		}
+		item.Repetitions++
+	}
+
+	q := float64(5 - quality)
+	item.EaseFactor += 0.1 - q*(0.08+q*0.02)
+	if item.EaseFactor < minimumEase {
+		item.EaseFactor = minimumEase
+	}
+
+	item.LastReviewed = now
+	item.Due = now.AddDate(0, 0, item.Interval)
+	return item
+}
+
+// IsDue reports whether item falls due at any point on now's calendar day.
+func IsDue(item models.ReviewItem, now time.Time) bool {
+	y, m, d := now.Date()
+	endOfDay := time.Date(y, m, d+1, 0, 0, 0, 0, now.Location())
+	return item.Due.Before(endOfDay)
+}
+
+// Due returns the IDs of the exercises due today, most overdue first.
+func Due(items map[string]models.ReviewItem, now time.Time) []string {
+	var due []string
+	for id, item := range items {
+		if IsDue(item, now) {
+			due = append(due, id)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		a, b := items[due[i]], items[due[j]]
+		if !a.Due.Equal(b.Due) {
+			return a.Due.Before(b.Due)
+		}
+		return due[i] < due[j]
+	})
+	return due
+}
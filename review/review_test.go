@@ -0,0 +1,53 @@
+package review
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/ghousemohamed/regex-in-the-terminal/models"
+)
+
+func TestSchedule(t *testing.T) {
+	now := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+
+	// Each step reviews the item left by the one before.
+	steps := []struct {
+		name        string
+		quality     int
+		interval    int
+		repetitions int
+		ease        float64
+	}{
+		{"first review", 5, 1, 1, 2.6},
+		{"second review", 4, 6, 2, 2.6},
+		{"third review grows by the ease", 3, 16, 3, 2.46},
+		{"a lapse starts over", 2, 1, 0, 2.14},
+		{"relearned", 5, 1, 1, 2.24},
+	}
+
+	var item models.ReviewItem
+	for _, s := range steps {
+		item = Schedule(item, s.quality, now)
+		if item.Interval != s.interval || item.Repetitions != s.repetitions || math.Abs(item.EaseFactor-s.ease) > 1e-9 {
+			t.Errorf("%s: interval %d, repetitions %d, ease %.2f; want %d, %d, %.2f",
+				s.name, item.Interval, item.Repetitions, item.EaseFactor, s.interval, s.repetitions, s.ease)
+		}
+		if !item.LastReviewed.Equal(now) {
+			t.Errorf("%s: last reviewed %v, want %v", s.name, item.LastReviewed, now)
+		}
+		if want := now.AddDate(0, 0, s.interval); !item.Due.Equal(want) {
+			t.Errorf("%s: due %v, want %v", s.name, item.Due, want)
+		}
+	}
+}
+
+func TestScheduleEaseFloor(t *testing.T) {
+	item := models.ReviewItem{}
+	for i := 0; i < 10; i++ {
+		item = Schedule(item, 0, time.Now())
+	}
+	if item.EaseFactor != minimumEase {
+		t.Errorf("ease after repeated failures = %.2f, want %.2f", item.EaseFactor, minimumEase)
+	}
+}
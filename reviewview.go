@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func (m model) reviewView(header string, totalWidth int) string {
+	if len(m.reviewQueue) == 0 {
+		body := lipgloss.JoinVertical(lipgloss.Center,
+			successStyle.Render("No reviews due"),
+			"",
+			"Everything you've completed is fresh for now. Come back tomorrow!",
+			"",
+			incompletedStyle.Render(fmt.Sprintf("Press %s or %s to go to main screen", keyName(keys.Select), keyName(keys.Back))),
+		)
+		return lipgloss.JoinVertical(lipgloss.Center,
+			header,
+			lipgloss.NewStyle().
+				Width(totalWidth-4).
+				Padding(2).
+				Border(lipgloss.RoundedBorder()).
+				BorderForeground(successColor).
+				Align(lipgloss.Center).
+				Render(body),
+		)
+	}
+
+	return m.exerciseView(header, totalWidth)
+}
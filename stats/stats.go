@@ -0,0 +1,176 @@
+package stats
+
+import (
+	"sort"
+	"time"
+
+	"github.com/ghousemohamed/regex-in-the-terminal/models"
+)
+
+const dateLayout = "2006-01-02"
+
+// Exercise names an exercise so the summary can be reported in course
+// order with readable titles.
+type Exercise struct {
+	ID    string
+	Title string
+}
+
+type ExerciseStats struct {
+	ID        string `json:"id"`
+	Title     string `json:"title"`
+	Attempts  int    `json:"attempts"`
+	Solved    bool   `json:"solved"`
+	FirstTry  bool   `json:"first_try"`
+	HintsUsed int    `json:"hints_used"`
+}
+
+type Summary struct {
+	TotalSeconds  int64           `json:"total_seconds"`
+	Attempts      int             `json:"attempts"`
+	Solved        int             `json:"solved"`
+	FirstTryRate  float64         `json:"first_try_rate"`
+	HintsUsed     int             `json:"hints_used"`
+	Exercises     []ExerciseStats `json:"exercises"`
+	Hardest       []ExerciseStats `json:"hardest"`
+	ActiveDays    []string        `json:"active_days"`
+	CurrentStreak int             `json:"current_streak"`
+	LongestStreak int             `json:"longest_streak"`
+	GeneratedAt   time.Time       `json:"generated_at"`
+}
+
+// TotalTime is TotalSeconds as a duration.
+func (s Summary) TotalTime() time.Duration {
+	return time.Duration(s.TotalSeconds) * time.Second
+}
+
+// Compute summarises the attempt history. Time spent is reconstructed from
+// each attempt's time since its exercise was opened: a run of attempts at
+// the same exercise with growing elapsed times counts once, up to its last
+// attempt.
+func Compute(attempts []models.Attempt, hints map[string]int, exercises []Exercise, now time.Time) Summary {
+	summary := Summary{GeneratedAt: now}
+
+	perExercise := map[string]*ExerciseStats{}
+	days := map[string]bool{}
+	var total time.Duration
+	var prev *models.Attempt
+
+	for i := range attempts {
+		a := &attempts[i]
+		summary.Attempts++
+		days[a.Time.Local().Format(dateLayout)] = true
+
+		if prev != nil && prev.ExerciseID == a.ExerciseID && a.Elapsed >= prev.Elapsed {
+			total += a.Elapsed - prev.Elapsed
+		} else {
+			total += a.Elapsed
+		}
+		prev = a
+
+		s, ok := perExercise[a.ExerciseID]
+		if !ok {
+			s = &ExerciseStats{ID: a.ExerciseID}
+			perExercise[a.ExerciseID] = s
+		}
+		if s.Solved {
+			continue
+		}
+		s.Attempts++
+		if a.Passed {
+			s.Solved = true
+			s.FirstTry = s.Attempts == 1
+		}
+	}
+	summary.TotalSeconds = int64(total / time.Second)
+
+	for id, n := range hints {
+		summary.HintsUsed += n
+		if s, ok := perExercise[id]; ok {
+			s.HintsUsed = n
+		} else if n > 0 {
+			perExercise[id] = &ExerciseStats{ID: id, HintsUsed: n}
+		}
+	}
+
+	firstTries := 0
+	for _, e := range exercises {
+		s, ok := perExercise[e.ID]
+		if !ok {
+			continue
+		}
+		s.Title = e.Title
+		if s.Solved {
+			summary.Solved++
+			if s.FirstTry {
+				firstTries++
+			}
+		}
+		summary.Exercises = append(summary.Exercises, *s)
+	}
+	if summary.Solved > 0 {
+		summary.FirstTryRate = float64(firstTries) / float64(summary.Solved)
+	}
+
+	for _, e := range summary.Exercises {
+		if e.Attempts > 0 {
+			summary.Hardest = append(summary.Hardest, e)
+		}
+	}
+	sort.SliceStable(summary.Hardest, func(i, j int) bool {
+		return summary.Hardest[i].Attempts > summary.Hardest[j].Attempts
+	})
+	if len(summary.Hardest) > 5 {
+		summary.Hardest = summary.Hardest[:5]
+	}
+
+	for day := range days {
+		summary.ActiveDays = append(summary.ActiveDays, day)
+	}
+	sort.Strings(summary.ActiveDays)
+	summary.CurrentStreak, summary.LongestStreak = streaks(days, now)
+
+	return summary
+}
+
+// streaks returns the run of consecutive active days ending today (or
+// yesterday, so a streak isn't lost before the day is over) and the longest
+// such run ever.
+func streaks(days map[string]bool, now time.Time) (current, longest int) {
+	var dates []time.Time
+	for day := range days {
+		if t, err := time.ParseInLocation(dateLayout, day, now.Location()); err == nil {
+			dates = append(dates, t)
+		}
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+
+	run := 0
+	for i, d := range dates {
+		if i > 0 && dates[i-1].AddDate(0, 0, 1).Equal(d) {
+			run++
+		} else {
+			run = 1
+		}
+		if run > longest {
+			longest = run
+		}
+	}
+
+	day := now
+	if !days[day.Format(dateLayout)] {
+		day = day.AddDate(0, 0, -1)
+	}
+	for days[day.Format(dateLayout)] {
+		current++
+		day = day.AddDate(0, 0, -1)
+	}
+	return current, longest
+}
+
+// Active reports whether the summary has activity on day.
+func (s Summary) Active(day time.Time) bool {
+	key := day.Format(dateLayout)
+	i := sort.SearchStrings(s.ActiveDays, key)
+	return i < len(s.ActiveDays) && s.ActiveDays[i] == key
+}
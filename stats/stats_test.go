@@ -0,0 +1,63 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ghousemohamed/regex-in-the-terminal/models"
+)
+
+func TestComputeStreaks(t *testing.T) {
+	now := time.Date(2024, 5, 20, 18, 0, 0, 0, time.Local)
+	day := func(daysAgo int) time.Time {
+		return now.AddDate(0, 0, -daysAgo).Add(-time.Hour)
+	}
+
+	tests := []struct {
+		name             string
+		daysAgo          []int
+		current, longest int
+	}{
+		{"no activity", nil, 0, 0},
+		{"today only", []int{0}, 1, 1},
+		{"run ending today", []int{0, 1, 2}, 3, 3},
+		{"run ending yesterday is still current", []int{1, 2}, 2, 2},
+		{"run ending two days ago is over", []int{2, 3, 4}, 0, 3},
+		{"longest run in the past", []int{0, 1, 5, 6, 7, 8}, 2, 4},
+		{"several attempts a day count once", []int{0, 0, 0, 1}, 2, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts []models.Attempt
+			for i := len(tt.daysAgo) - 1; i >= 0; i-- {
+				attempts = append(attempts, models.Attempt{ExerciseID: "lesson/dot", Pattern: "a", Time: day(tt.daysAgo[i])})
+			}
+			s := Compute(attempts, nil, nil, now)
+			if s.CurrentStreak != tt.current || s.LongestStreak != tt.longest {
+				t.Errorf("streaks = %d current, %d longest; want %d, %d",
+					s.CurrentStreak, s.LongestStreak, tt.current, tt.longest)
+			}
+		})
+	}
+}
+
+func TestComputeFirstTry(t *testing.T) {
+	now := time.Date(2024, 5, 20, 18, 0, 0, 0, time.Local)
+	attempts := []models.Attempt{
+		{ExerciseID: "lesson/dot", Pattern: "a.c", Passed: true, Time: now},
+		{ExerciseID: "lesson/anchors", Pattern: "a", Time: now},
+		{ExerciseID: "lesson/anchors", Pattern: "^a$", Passed: true, Time: now},
+		{ExerciseID: "lesson/anchors", Pattern: "^a", Passed: true, Time: now},
+	}
+	exercises := []Exercise{{"lesson/dot", "Dot"}, {"lesson/anchors", "Anchors"}}
+
+	s := Compute(attempts, map[string]int{"lesson/anchors": 2}, exercises, now)
+	if s.Attempts != 4 || s.Solved != 2 || s.FirstTryRate != 0.5 || s.HintsUsed != 2 {
+		t.Errorf("summary = %d attempts, %d solved, %.2f first try, %d hints; want 4, 2, 0.50, 2",
+			s.Attempts, s.Solved, s.FirstTryRate, s.HintsUsed)
+	}
+	if len(s.Exercises) != 2 || s.Exercises[1].Attempts != 2 || s.Exercises[1].FirstTry {
+		t.Errorf("exercises = %+v, want anchors solved on the second of 2 attempts", s.Exercises)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/ghousemohamed/regex-in-the-terminal/stats"
+)
+
+const calendarWeeks = 16
+
+// streakCalendar draws the last weeks weeks as a grid with one column per
+// week and one row per weekday, ending with the current week.
+func streakCalendar(summary stats.Summary, weeks int, now time.Time) string {
+	// Start on the Monday weeks-1 weeks before this week's Monday.
+	offset := (int(now.Weekday()) + 6) % 7
+	start := now.AddDate(0, 0, -offset-7*(weeks-1))
+
+	var b strings.Builder
+	for row, name := range []string{"Mon", "   ", "Wed", "   ", "Fri", "   ", "Sun"} {
+		b.WriteString(incompletedStyle.Render(name) + " ")
+		for week := 0; week < weeks; week++ {
+			day := start.AddDate(0, 0, week*7+row)
+			switch {
+			case day.After(now):
+				b.WriteString("  ")
+			case summary.Active(day):
+				b.WriteString(completedStyle.Render("■ "))
+			default:
+				b.WriteString(incompletedStyle.Render("· "))
+			}
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d < time.Hour {
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%dh %dm", int(d.Hours()), int(d.Minutes())%60)
+}
+
+func (m model) statsView(header string, totalWidth int) string {
+	s := m.summary
+	l := columnLayout(totalWidth)
+	if l.mode == drawer {
+		// There is no drawer here; the boxes are stacked instead.
+		l.mode = stacked
+	}
+
+	var overview strings.Builder
+	overview.WriteString(gradientText("Statistics") + "\n\n")
+	overview.WriteString(fmt.Sprintf("Total time spent:    %s\n", formatDuration(s.TotalTime())))
+	overview.WriteString(fmt.Sprintf("Patterns submitted:  %d\n", s.Attempts))
+	overview.WriteString(fmt.Sprintf("Exercises solved:    %d\n", s.Solved))
+	overview.WriteString(fmt.Sprintf("First-try success:   %.0f%%\n", s.FirstTryRate*100))
+	overview.WriteString(fmt.Sprintf("Hints used:          %d\n", s.HintsUsed))
+	overview.WriteString(fmt.Sprintf("Current streak:      %d days (longest %d)\n\n", s.CurrentStreak, s.LongestStreak))
+
+	overview.WriteString(successStyle.Render("Hardest exercises") + "\n")
+	if len(s.Hardest) == 0 {
+		overview.WriteString(incompletedStyle.Render("Nothing attempted yet") + "\n")
+	}
+	for _, e := range s.Hardest {
+		overview.WriteString(fmt.Sprintf("%3d attempts  %s\n", e.Attempts, e.Title))
+	}
+
+	overview.WriteString("\n" + successStyle.Render("Activity") + "\n")
+	// Each week is two columns wide, after four for the day names.
+	weeks := max(min(calendarWeeks, (l.contentWidth-4)/2), 1)
+	overview.WriteString(streakCalendar(s, weeks, time.Now()))
+
+	var perLesson strings.Builder
+	perLesson.WriteString(gradientText("Attempts per Exercise") + "\n\n")
+	for _, e := range s.Exercises {
+		status := "○"
+		style := incompletedStyle
+		if e.Solved {
+			status = "✓"
+			style = completedStyle
+		}
+		line := fmt.Sprintf("%s %3d  %s", status, e.Attempts, e.Title)
+		if e.FirstTry {
+			line += " (first try)"
+		}
+		perLesson.WriteString(style.Render(line) + "\n")
+	}
+
+	columns := l.join(
+		l.contentStyle().Render(overview.String()),
+		l.tocBoxStyle().Render(perLesson.String()),
+	)
+
+	doc := strings.Builder{}
+	doc.WriteString(header + "\n")
+	doc.WriteString(lipgloss.NewStyle().Align(lipgloss.Center).Render(columns))
+	doc.WriteString("\n\nPress " + keyName(keys.Back) + " for main menu\n")
+
+	return docStyle.Copy().Width(totalWidth).Render(doc.String())
+}
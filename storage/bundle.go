@@ -0,0 +1,209 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ghousemohamed/regex-in-the-terminal/models"
+)
+
+// BundleVersion is the format version written by Export. Import refuses
+// bundles from a newer version.
+const BundleVersion = 1
+
+// Bundle is a portable copy of a profile, for moving progress between
+// machines.
+type Bundle struct {
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+	Profile    string    `json:"profile"`
+
+	Progress models.Progress  `json:"progress"`
+	Attempts []models.Attempt `json:"attempts"`
+	// Solved is the shortest passing pattern per exercise ID, taken from
+	// the progress and the attempt history.
+	Solved map[string]string `json:"solved"`
+	Notes  map[string]string `json:"notes"`
+}
+
+// Export collects everything in s into a bundle.
+func Export(s Store, profile string) (Bundle, error) {
+	b := Bundle{
+		Version:    BundleVersion,
+		ExportedAt: time.Now(),
+		Profile:    profile,
+		Solved:     map[string]string{},
+	}
+
+	var err error
+	if b.Progress, err = s.LoadProgress(); err != nil {
+		return b, err
+	}
+	if b.Attempts, err = s.LoadAttempts(); err != nil {
+		return b, err
+	}
+	if b.Notes, err = s.LoadNotes(); err != nil {
+		return b, err
+	}
+
+	for id, pattern := range b.Progress.Solved {
+		b.Solved[id] = pattern
+	}
+	for _, a := range b.Attempts {
+		if a.Passed && betterSolution(a.Pattern, b.Solved[a.ExerciseID]) {
+			b.Solved[a.ExerciseID] = a.Pattern
+		}
+	}
+	return b, nil
+}
+
+func WriteBundle(w io.Writer, b Bundle) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(b)
+}
+
+func ReadBundle(r io.Reader) (Bundle, error) {
+	var b Bundle
+	if err := json.NewDecoder(r).Decode(&b); err != nil {
+		return b, fmt.Errorf("reading bundle: %w", err)
+	}
+	if b.Version < 1 || b.Version > BundleVersion {
+		return b, fmt.Errorf("bundle version %d is not supported; this version of learn-regex reads up to %d", b.Version, BundleVersion)
+	}
+	return b, nil
+}
+
+// ImportResult counts what an import changed.
+type ImportResult struct {
+	Attempts int
+	Solved   int
+	Notes    int
+}
+
+// Import adds a bundle to s. Attempts already in the history are skipped,
+// so importing the same bundle twice is harmless.
+//
+// When replace is false, progress is merged: completions are the union of
+// both sides, the shorter solution of each exercise is kept, and for
+// reviews and hint counts whichever side is further along wins. Notes only
+// fill in exercises without a local note. When replace is true the bundle's
+// progress and notes overwrite the local ones.
+func Import(s Store, b Bundle, replace bool) (ImportResult, error) {
+	var result ImportResult
+
+	err := s.UpdateProgress(func(progress *models.Progress) {
+		if replace {
+			// b.Solved already includes the bundle's own progress.Solved.
+			*progress = b.Progress
+			progress.Solved = nil
+		} else {
+			mergeProgress(progress, b.Progress)
+		}
+
+		if progress.Solved == nil {
+			progress.Solved = map[string]string{}
+		}
+		for id, pattern := range b.Solved {
+			if betterSolution(pattern, progress.Solved[id]) {
+				progress.Solved[id] = pattern
+				result.Solved++
+			}
+		}
+	})
+	if err != nil {
+		return result, err
+	}
+
+	existing, err := s.LoadAttempts()
+	if err != nil {
+		return result, err
+	}
+	seen := map[string]bool{}
+	for _, a := range existing {
+		seen[attemptKey(a)] = true
+	}
+	for _, a := range b.Attempts {
+		if seen[attemptKey(a)] {
+			continue
+		}
+		seen[attemptKey(a)] = true
+		if err := s.RecordAttempt(a); err != nil {
+			return result, err
+		}
+		result.Attempts++
+	}
+
+	notes, err := s.LoadNotes()
+	if err != nil {
+		return result, err
+	}
+	if replace {
+		for id := range notes {
+			if _, ok := b.Notes[id]; !ok {
+				if err := s.SaveNote(id, ""); err != nil {
+					return result, err
+				}
+			}
+		}
+	}
+	for id, text := range b.Notes {
+		if notes[id] == text || (!replace && notes[id] != "") {
+			continue
+		}
+		if err := s.SaveNote(id, text); err != nil {
+			return result, err
+		}
+		result.Notes++
+	}
+	return result, nil
+}
+
+func attemptKey(a models.Attempt) string {
+	return fmt.Sprintf("%s\x00%d\x00%s", a.ExerciseID, a.Time.UnixNano(), a.Pattern)
+}
+
+// mergeProgress folds other into progress. The position in the lessons and
+// practice problems stays local.
+func mergeProgress(progress *models.Progress, other models.Progress) {
+	progress.Completed = unionIDs(progress.Completed, other.Completed)
+	progress.CompletedPractice = unionIDs(progress.CompletedPractice, other.CompletedPractice)
+
+	for id, item := range other.Reviews {
+		if progress.Reviews == nil {
+			progress.Reviews = map[string]models.ReviewItem{}
+		}
+		if local, ok := progress.Reviews[id]; !ok || item.LastReviewed.After(local.LastReviewed) {
+			progress.Reviews[id] = item
+		}
+	}
+
+	for id, at := range other.Badges {
+		if progress.Badges == nil {
+			progress.Badges = map[string]time.Time{}
+		}
+		if local, ok := progress.Badges[id]; !ok || at.Before(local) {
+			progress.Badges[id] = at
+		}
+	}
+
+	// Dates in 2006-01-02 form sort chronologically.
+	longest := max(progress.Daily.Longest, other.Daily.Longest)
+	if other.Daily.LastSolved > progress.Daily.LastSolved {
+		progress.Daily = other.Daily
+	}
+	progress.Daily.Longest = longest
+
+	// Hint counts can't be told apart once merged, so take the larger
+	// rather than double counting hints seen on both machines.
+	for id, n := range other.HintsUsed {
+		if progress.HintsUsed == nil {
+			progress.HintsUsed = map[string]int{}
+		}
+		if n > progress.HintsUsed[id] {
+			progress.HintsUsed[id] = n
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// backupCount is how many previous versions of the progress file are kept,
+// as progress.json.1 (newest) to progress.json.3 (oldest).
+const backupCount = 3
+
+func backupName(path string, n int) string {
+	return fmt.Sprintf("%s.%d", path, n)
+}
+
+// writeFileAtomic replaces path with data so that readers, and the file
+// after a crash, see either the old contents or the new, never a mix.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	if err := os.Chmod(tmpName, 0644); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	return nil
+}
+
+// rotateBackups shifts the numbered backups of path down by one and copies
+// the current file into the first slot. valid decides whether the current
+// file is worth keeping, so a corrupt file never pushes out a good backup.
+func rotateBackups(path string, valid func([]byte) bool) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !valid(data) {
+		return nil
+	}
+
+	for n := backupCount; n > 1; n-- {
+		if err := os.Rename(backupName(path, n-1), backupName(path, n)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return writeFileAtomic(backupName(path, 1), data)
+}
@@ -0,0 +1,241 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ghousemohamed/regex-in-the-terminal/models"
+)
+
+const (
+	progressFileName = "progress.json"
+	historyFileName  = "history.jsonl"
+	settingsFileName = "settings.json"
+	notesFileName    = "notes.json"
+	lockFileName     = ".lock"
+)
+
+// JSONStore keeps a profile as plain files in one directory: progress and
+// settings as JSON documents, the attempt history as JSON lines. Every write
+// happens under an advisory lock on the directory.
+type JSONStore struct {
+	progressFile string
+	historyFile  string
+	settingsFile string
+	notesFile    string
+	lockFile     string
+}
+
+// OpenJSON returns a store for the files in dir, creating dir if needed.
+func OpenJSON(dir string) (*JSONStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &JSONStore{
+		progressFile: filepath.Join(dir, progressFileName),
+		historyFile:  filepath.Join(dir, historyFileName),
+		settingsFile: filepath.Join(dir, settingsFileName),
+		notesFile:    filepath.Join(dir, notesFileName),
+		lockFile:     filepath.Join(dir, lockFileName),
+	}, nil
+}
+
+// LoadProgress reads the stored progress. If the file is corrupt, it is set
+// aside as progress.json.corrupt and the newest readable backup is returned
+// instead.
+func (s *JSONStore) LoadProgress() (models.Progress, error) {
+	var progress models.Progress
+	data, err := os.ReadFile(s.progressFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return progress, nil
+		}
+		return progress, err
+	}
+
+	err = json.Unmarshal(data, &progress)
+	if err == nil {
+		return progress, nil
+	}
+
+	os.WriteFile(s.progressFile+".corrupt", data, 0644)
+	for n := 1; n <= backupCount; n++ {
+		backup, readErr := os.ReadFile(backupName(s.progressFile, n))
+		if readErr != nil {
+			continue
+		}
+		var recovered models.Progress
+		if json.Unmarshal(backup, &recovered) == nil {
+			return recovered, nil
+		}
+	}
+	return models.Progress{}, fmt.Errorf("%s: %w: %v", s.progressFile, ErrCorrupt, err)
+}
+
+// UpdateProgress applies update under the profile lock. Progress that is
+// corrupt beyond recovery has already been set aside by LoadProgress, so
+// the update starts afresh rather than failing forever.
+func (s *JSONStore) UpdateProgress(update func(*models.Progress)) error {
+	return withLock(s.lockFile, func() error {
+		progress, err := s.LoadProgress()
+		if errors.Is(err, ErrCorrupt) {
+			progress, err = models.Progress{}, nil
+		}
+		if err != nil {
+			return err
+		}
+
+		update(&progress)
+		return s.writeProgress(progress)
+	})
+}
+
+func validProgress(data []byte) bool {
+	var progress models.Progress
+	return json.Unmarshal(data, &progress) == nil
+}
+
+// writeProgress replaces the progress file atomically, keeping the previous
+// versions as backups. Callers must hold the profile lock.
+func (s *JSONStore) writeProgress(progress models.Progress) error {
+	data, err := json.Marshal(progress)
+	if err != nil {
+		return err
+	}
+
+	if err := rotateBackups(s.progressFile, validProgress); err != nil {
+		return err
+	}
+	return writeFileAtomic(s.progressFile, data)
+}
+
+// RecordAttempt appends a single attempt to the history file. The file is
+// one JSON object per line and is never rewritten.
+func (s *JSONStore) RecordAttempt(attempt models.Attempt) error {
+	data, err := json.Marshal(attempt)
+	if err != nil {
+		return err
+	}
+
+	return withLock(s.lockFile, func() error {
+		f, err := os.OpenFile(s.historyFile, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0644)
+		if err != nil {
+			return err
+		}
+
+		// A crash mid-append leaves a line without its newline. End it
+		// first, so that only the torn line is lost and not this one too.
+		line := append(data, '\n')
+		if info, err := f.Stat(); err == nil && info.Size() > 0 {
+			last := make([]byte, 1)
+			if _, err := f.ReadAt(last, info.Size()-1); err != nil {
+				f.Close()
+				return err
+			}
+			if last[0] != '\n' {
+				line = append([]byte{'\n'}, line...)
+			}
+		}
+
+		if _, err := f.Write(line); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	})
+}
+
+// LoadAttempts returns every recorded attempt, oldest first. Lines that fail
+// to parse, such as a line cut short by a crash, are skipped.
+func (s *JSONStore) LoadAttempts() ([]models.Attempt, error) {
+	f, err := os.Open(s.historyFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var attempts []models.Attempt
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var attempt models.Attempt
+		if err := json.Unmarshal(scanner.Bytes(), &attempt); err != nil {
+			continue
+		}
+		attempts = append(attempts, attempt)
+	}
+	return attempts, scanner.Err()
+}
+
+func (s *JSONStore) LoadNotes() (map[string]string, error) {
+	notes := map[string]string{}
+	data, err := os.ReadFile(s.notesFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return notes, nil
+		}
+		return nil, err
+	}
+
+	err = json.Unmarshal(data, &notes)
+	return notes, err
+}
+
+func (s *JSONStore) SaveNote(exerciseID, text string) error {
+	return withLock(s.lockFile, func() error {
+		notes, err := s.LoadNotes()
+		if err != nil {
+			return err
+		}
+		if text == "" {
+			delete(notes, exerciseID)
+		} else {
+			notes[exerciseID] = text
+		}
+
+		data, err := json.MarshalIndent(notes, "", "  ")
+		if err != nil {
+			return err
+		}
+		return writeFileAtomic(s.notesFile, data)
+	})
+}
+
+// LoadSettings returns the stored settings, or the zero value if none have
+// been saved.
+func (s *JSONStore) LoadSettings() (models.Settings, error) {
+	var settings models.Settings
+	data, err := os.ReadFile(s.settingsFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return settings, nil
+		}
+		return settings, err
+	}
+
+	err = json.Unmarshal(data, &settings)
+	return settings, err
+}
+
+func (s *JSONStore) SaveSettings(settings models.Settings) error {
+	data, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return withLock(s.lockFile, func() error {
+		return writeFileAtomic(s.settingsFile, data)
+	})
+}
+
+// Close does nothing; a JSONStore holds no open files between calls.
+func (s *JSONStore) Close() error {
+	return nil
+}
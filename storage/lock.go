@@ -0,0 +1,21 @@
+package storage
+
+import "os"
+
+// withLock runs fn while holding an exclusive advisory lock on path, so
+// that several running instances don't interleave their read-modify-write
+// cycles. It must not be nested.
+func withLock(path string, fn func() error) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := lockFileHandle(f); err != nil {
+		return err
+	}
+	defer unlockFileHandle(f)
+
+	return fn()
+}
@@ -0,0 +1,15 @@
+//go:build !unix && !windows
+
+package storage
+
+import "os"
+
+// Platforms without advisory locks run unlocked.
+
+func lockFileHandle(*os.File) error {
+	return nil
+}
+
+func unlockFileHandle(*os.File) error {
+	return nil
+}
@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/ghousemohamed/regex-in-the-terminal/models"
+)
+
+// MemoryStore keeps everything in memory and forgets it on exit. It is
+// meant for tests and for trying the app without touching the disk.
+type MemoryStore struct {
+	mu       sync.Mutex
+	progress models.Progress
+	attempts []models.Attempt
+	notes    map[string]string
+	settings models.Settings
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{notes: map[string]string{}}
+}
+
+func (s *MemoryStore) LoadProgress() (models.Progress, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return copyProgress(s.progress), nil
+}
+
+func (s *MemoryStore) UpdateProgress(update func(*models.Progress)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	progress := copyProgress(s.progress)
+	update(&progress)
+	s.progress = progress
+	return nil
+}
+
+// copyProgress returns progress with its own slices and maps, so callers
+// can't change the stored copy behind the store's back.
+func copyProgress(progress models.Progress) models.Progress {
+	progress.Completed = append([]string(nil), progress.Completed...)
+	progress.CompletedPractice = append([]string(nil), progress.CompletedPractice...)
+	if progress.Reviews != nil {
+		reviews := make(map[string]models.ReviewItem, len(progress.Reviews))
+		for id, item := range progress.Reviews {
+			reviews[id] = item
+		}
+		progress.Reviews = reviews
+	}
+	if progress.HintsUsed != nil {
+		hints := make(map[string]int, len(progress.HintsUsed))
+		for id, n := range progress.HintsUsed {
+			hints[id] = n
+		}
+		progress.HintsUsed = hints
+	}
+	if progress.Solved != nil {
+		solved := make(map[string]string, len(progress.Solved))
+		for id, pattern := range progress.Solved {
+			solved[id] = pattern
+		}
+		progress.Solved = solved
+	}
+	if progress.Drafts != nil {
+		drafts := make(map[string]string, len(progress.Drafts))
+		for id, draft := range progress.Drafts {
+			drafts[id] = draft
+		}
+		progress.Drafts = drafts
+	}
+	if progress.Badges != nil {
+		badges := make(map[string]time.Time, len(progress.Badges))
+		for id, at := range progress.Badges {
+			badges[id] = at
+		}
+		progress.Badges = badges
+	}
+	if progress.LastReset != nil {
+		snapshot := *progress.LastReset
+		snapshot.Completed = append([]string(nil), snapshot.Completed...)
+		snapshot.CompletedPractice = append([]string(nil), snapshot.CompletedPractice...)
+		snapshot.Reviews = maps.Clone(snapshot.Reviews)
+		snapshot.Solved = maps.Clone(snapshot.Solved)
+		snapshot.Badges = maps.Clone(snapshot.Badges)
+		progress.LastReset = &snapshot
+	}
+	return progress
+}
+
+func (s *MemoryStore) RecordAttempt(attempt models.Attempt) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attempts = append(s.attempts, attempt)
+	return nil
+}
+
+func (s *MemoryStore) LoadAttempts() ([]models.Attempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]models.Attempt(nil), s.attempts...), nil
+}
+
+func (s *MemoryStore) LoadNotes() (map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	notes := make(map[string]string, len(s.notes))
+	for id, text := range s.notes {
+		notes[id] = text
+	}
+	return notes, nil
+}
+
+func (s *MemoryStore) SaveNote(exerciseID, text string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if text == "" {
+		delete(s.notes, exerciseID)
+	} else {
+		s.notes[exerciseID] = text
+	}
+	return nil
+}
+
+func (s *MemoryStore) LoadSettings() (models.Settings, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.settings, nil
+}
+
+func (s *MemoryStore) SaveSettings(settings models.Settings) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.settings = settings
+	return nil
+}
+
+func (s *MemoryStore) Close() error {
+	return nil
+}
@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+// DefaultProfile is the profile used when none is chosen.
+const DefaultProfile = "default"
+
+var validProfileName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,31}$`)
+
+// DataDir is where all profiles are stored: $XDG_DATA_HOME/learn-regex,
+// falling back to ~/.local/share/learn-regex as the XDG spec says.
+func DataDir() string {
+	if dir := os.Getenv("XDG_DATA_HOME"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, "learn-regex")
+	}
+	return filepath.Join(homeDir(), ".local", "share", "learn-regex")
+}
+
+func homeDir() string {
+	if home, err := os.UserHomeDir(); err == nil {
+		return home
+	}
+	return os.Getenv("HOME")
+}
+
+// ProfileDir is the directory holding everything stored for the named
+// profile.
+func ProfileDir(name string) string {
+	return filepath.Join(DataDir(), "profiles", name)
+}
+
+// ValidateProfileName reports why name can't be used as a profile name,
+// or nil if it can.
+func ValidateProfileName(name string) error {
+	if !validProfileName.MatchString(name) {
+		return fmt.Errorf("profile names must be 1-32 letters, digits, '.', '_' or '-', starting with a letter or digit")
+	}
+	return nil
+}
+
+// legacyFiles returns where versions before XDG support kept the files of
+// the named profile, in the order progress, history, settings.
+func legacyFiles(name string) []string {
+	home := homeDir()
+	if name == DefaultProfile {
+		return []string{
+			filepath.Join(home, ".regex_tutorial_progress.json"),
+			filepath.Join(home, ".regex_tutorial_history.jsonl"),
+			filepath.Join(home, ".regex_tutorial_settings.json"),
+		}
+	}
+	dir := filepath.Join(home, ".regex_tutorial_profiles", name)
+	return []string{
+		filepath.Join(dir, "progress.json"),
+		filepath.Join(dir, "history.jsonl"),
+		filepath.Join(dir, "settings.json"),
+	}
+}
+
+// migrateLegacyFiles moves the named profile's files from where older
+// versions kept them into dir. Callers must hold the profile lock.
+func migrateLegacyFiles(name, dir string) error {
+	for i, base := range []string{progressFileName, historyFileName, settingsFileName} {
+		dst := filepath.Join(dir, base)
+		src := legacyFiles(name)[i]
+		if _, err := os.Stat(dst); err == nil {
+			continue
+		}
+		if _, err := os.Stat(src); err != nil {
+			continue
+		}
+		if err := moveFile(src, dst); err != nil {
+			return fmt.Errorf("moving %s to %s: %w", src, dst, err)
+		}
+	}
+	if name != DefaultProfile {
+		// Only succeeds once the old profile directory is empty.
+		os.Remove(filepath.Dir(legacyFiles(name)[0]))
+	}
+	return nil
+}
+
+// moveFile renames src to dst, copying instead when they are on different
+// file systems.
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
+
+// Profiles lists every profile on this machine, the default one first.
+// Profiles still in the pre-XDG location are included; they move over the
+// first time they are used.
+func Profiles() ([]string, error) {
+	seen := map[string]bool{DefaultProfile: true}
+	var names []string
+	for _, dir := range []string{
+		filepath.Join(DataDir(), "profiles"),
+		filepath.Join(homeDir(), ".regex_tutorial_profiles"),
+	} {
+		entries, err := os.ReadDir(dir)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		for _, e := range entries {
+			if e.IsDir() && !seen[e.Name()] && ValidateProfileName(e.Name()) == nil {
+				seen[e.Name()] = true
+				names = append(names, e.Name())
+			}
+		}
+	}
+	sort.Strings(names)
+	return append([]string{DefaultProfile}, names...), nil
+}
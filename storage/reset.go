@@ -0,0 +1,156 @@
+package storage
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/ghousemohamed/regex-in-the-terminal/models"
+)
+
+// ErrNothingToUndo is returned by UndoReset when no reset has been made
+// since the last undo.
+var ErrNothingToUndo = errors.New("there is no reset to undo")
+
+// Reset marks the lesson ("learning") or practice problem ("practice") at
+// index as not completed, or all of them when index is negative, which also
+// moves back to the first one. The review schedule and best solution of
+// exerciseID, the exercise at index, go with it, or those of the whole
+// track when index is negative. The progress before the reset is kept as a
+// snapshot, described by description, that UndoReset restores.
+func Reset(s Store, clearType string, index int, exerciseID, description string) error {
+	return s.UpdateProgress(func(progress *models.Progress) {
+		snapshot := &models.ResetSnapshot{
+			Description:       description,
+			Time:              time.Now(),
+			CurrentLesson:     progress.CurrentLesson,
+			Completed:         append([]string(nil), progress.Completed...),
+			PracticeIndex:     progress.PracticeIndex,
+			CompletedPractice: append([]string(nil), progress.CompletedPractice...),
+		}
+		progress.LastReset = snapshot
+
+		switch {
+		case clearType == "practice" && index < 0:
+			progress.PracticeIndex = 0
+			progress.CompletedPractice = nil
+			exerciseID = "practice/"
+		case clearType == "practice":
+			progress.CompletedPractice = removeID(progress.CompletedPractice, strconv.Itoa(index))
+		case clearType == "learning" && index < 0:
+			progress.CurrentLesson = 0
+			progress.Completed = nil
+			exerciseID = "lesson/"
+		case clearType == "learning":
+			progress.Completed = removeID(progress.Completed, strconv.Itoa(index))
+		}
+
+		for id, item := range progress.Reviews {
+			if matchesExercise(exerciseID, id) {
+				if snapshot.Reviews == nil {
+					snapshot.Reviews = map[string]models.ReviewItem{}
+				}
+				snapshot.Reviews[id] = item
+				delete(progress.Reviews, id)
+			}
+		}
+		for id, pattern := range progress.Solved {
+			if matchesExercise(exerciseID, id) {
+				if snapshot.Solved == nil {
+					snapshot.Solved = map[string]string{}
+				}
+				snapshot.Solved[id] = pattern
+				delete(progress.Solved, id)
+			}
+		}
+	})
+}
+
+// RevokeBadges takes away the badges with the given IDs, which a reset has
+// left unearned. The last reset keeps them so that UndoReset gives them
+// back.
+func RevokeBadges(s Store, ids []string) error {
+	return s.UpdateProgress(func(progress *models.Progress) {
+		for _, id := range ids {
+			at, ok := progress.Badges[id]
+			if !ok {
+				continue
+			}
+			if progress.LastReset != nil {
+				if progress.LastReset.Badges == nil {
+					progress.LastReset.Badges = map[string]time.Time{}
+				}
+				progress.LastReset.Badges[id] = at
+			}
+			delete(progress.Badges, id)
+		}
+	})
+}
+
+func removeID(ids []string, id string) []string {
+	var kept []string
+	for _, other := range ids {
+		if other != id {
+			kept = append(kept, other)
+		}
+	}
+	return kept
+}
+
+// LastReset returns the snapshot UndoReset would restore, or nil.
+func LastReset(s Store) (*models.ResetSnapshot, error) {
+	progress, err := s.LoadProgress()
+	if err != nil {
+		return nil, err
+	}
+	return progress.LastReset, nil
+}
+
+// UndoReset restores the progress from before the last reset and returns
+// the snapshot it used. Completions, reviews and solutions made since the
+// reset are kept, though a shorter solution from before it wins.
+func UndoReset(s Store) (models.ResetSnapshot, error) {
+	var snapshot *models.ResetSnapshot
+	err := s.UpdateProgress(func(progress *models.Progress) {
+		snapshot = progress.LastReset
+		if snapshot == nil {
+			return
+		}
+		progress.CurrentLesson = snapshot.CurrentLesson
+		progress.Completed = unionIDs(progress.Completed, snapshot.Completed)
+		progress.PracticeIndex = snapshot.PracticeIndex
+		progress.CompletedPractice = unionIDs(progress.CompletedPractice, snapshot.CompletedPractice)
+		for id, item := range snapshot.Reviews {
+			if _, ok := progress.Reviews[id]; !ok {
+				if progress.Reviews == nil {
+					progress.Reviews = map[string]models.ReviewItem{}
+				}
+				progress.Reviews[id] = item
+			}
+		}
+		for id, pattern := range snapshot.Solved {
+			if betterSolution(pattern, progress.Solved[id]) {
+				if progress.Solved == nil {
+					progress.Solved = map[string]string{}
+				}
+				progress.Solved[id] = pattern
+			}
+		}
+		for id, at := range snapshot.Badges {
+			if _, ok := progress.Badges[id]; !ok {
+				if progress.Badges == nil {
+					progress.Badges = map[string]time.Time{}
+				}
+				progress.Badges[id] = at
+			}
+		}
+		progress.LastReset = nil
+	})
+	if err != nil {
+		return models.ResetSnapshot{}, err
+	}
+	if snapshot == nil {
+		return models.ResetSnapshot{}, ErrNothingToUndo
+	}
+	return *snapshot, nil
+}
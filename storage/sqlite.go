@@ -0,0 +1,243 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/ghousemohamed/regex-in-the-terminal/models"
+
+	_ "modernc.org/sqlite"
+)
+
+const sqliteFileName = "learn-regex.db"
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS progress (
+	id   INTEGER PRIMARY KEY CHECK (id = 1),
+	data TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS attempts (
+	id           INTEGER PRIMARY KEY AUTOINCREMENT,
+	exercise_id  TEXT    NOT NULL,
+	pattern      TEXT    NOT NULL,
+	passed       INTEGER NOT NULL,
+	time         INTEGER NOT NULL,
+	failing_case TEXT    NOT NULL DEFAULT '',
+	error        TEXT    NOT NULL DEFAULT '',
+	elapsed      INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS attempts_exercise ON attempts (exercise_id, time);
+CREATE INDEX IF NOT EXISTS attempts_time ON attempts (time);
+CREATE TABLE IF NOT EXISTS notes (
+	exercise_id TEXT PRIMARY KEY,
+	text        TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS settings (
+	id   INTEGER PRIMARY KEY CHECK (id = 1),
+	data TEXT NOT NULL
+);
+`
+
+// SQLiteStore keeps a profile in a single SQLite database. Attempts get a
+// row each, so the history can be filtered without reading all of it.
+// Progress and settings are stored as JSON documents, like the files of a
+// JSONStore, so new fields need no schema change.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// OpenSQLite opens the database at path, creating it and its tables if
+// needed.
+func OpenSQLite(path string) (*SQLiteStore, error) {
+	// Immediate transactions take the write lock up front, so two
+	// instances updating progress at once queue up instead of one failing
+	// halfway through.
+	dsn := (&url.URL{Scheme: "file", Path: path}).String() +
+		"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_txlock=immediate"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+// loadDocument unmarshals the single row of table into v, leaving v alone
+// when there is no row yet.
+func loadDocument(q interface {
+	QueryRow(query string, args ...any) *sql.Row
+}, table string, v any) error {
+	var data string
+	err := q.QueryRow("SELECT data FROM " + table + " WHERE id = 1").Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(data), v)
+}
+
+func saveDocument(e interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}, table string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = e.Exec("INSERT INTO "+table+" (id, data) VALUES (1, ?) "+
+		"ON CONFLICT (id) DO UPDATE SET data = excluded.data", string(data))
+	return err
+}
+
+func (s *SQLiteStore) LoadProgress() (models.Progress, error) {
+	var progress models.Progress
+	if err := loadDocument(s.db, "progress", &progress); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return models.Progress{}, fmt.Errorf("%w: %v", ErrCorrupt, err)
+		}
+		return models.Progress{}, err
+	}
+	return progress, nil
+}
+
+// UpdateProgress applies update inside a transaction, so it sees and
+// replaces the latest progress even with other instances writing.
+func (s *SQLiteStore) UpdateProgress(update func(*models.Progress)) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var progress models.Progress
+	if err := loadDocument(tx, "progress", &progress); err != nil {
+		var syntaxErr *json.SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			return err
+		}
+		progress = models.Progress{}
+	}
+
+	update(&progress)
+	if err := saveDocument(tx, "progress", progress); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLiteStore) RecordAttempt(a models.Attempt) error {
+	_, err := s.db.Exec(`INSERT INTO attempts
+		(exercise_id, pattern, passed, time, failing_case, error, elapsed)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		a.ExerciseID, a.Pattern, a.Passed, a.Time.UnixNano(), a.FailingCase, a.Error, int64(a.Elapsed))
+	return err
+}
+
+func (s *SQLiteStore) LoadAttempts() ([]models.Attempt, error) {
+	return s.History(HistoryQuery{})
+}
+
+// History runs q as a query, so that only the matching attempts are read.
+func (s *SQLiteStore) History(q HistoryQuery) ([]models.Attempt, error) {
+	query := "SELECT exercise_id, pattern, passed, time, failing_case, error, elapsed FROM attempts WHERE 1 = 1"
+	var args []any
+	if q.ExerciseID != "" {
+		if q.ExerciseID[len(q.ExerciseID)-1] == '/' {
+			query += " AND substr(exercise_id, 1, ?) = ?"
+			args = append(args, len(q.ExerciseID), q.ExerciseID)
+		} else {
+			query += " AND exercise_id = ?"
+			args = append(args, q.ExerciseID)
+		}
+	}
+	if q.FailedOnly {
+		query += " AND passed = 0"
+	}
+	if !q.Since.IsZero() {
+		query += " AND time >= ?"
+		args = append(args, q.Since.UnixNano())
+	}
+	query += " ORDER BY id DESC"
+	if q.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, q.Limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attempts []models.Attempt
+	for rows.Next() {
+		var a models.Attempt
+		var at, elapsed int64
+		if err := rows.Scan(&a.ExerciseID, &a.Pattern, &a.Passed, &at, &a.FailingCase, &a.Error, &elapsed); err != nil {
+			return nil, err
+		}
+		a.Time = time.Unix(0, at)
+		a.Elapsed = time.Duration(elapsed)
+		attempts = append(attempts, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Rows came newest first so LIMIT keeps the most recent ones.
+	for i, j := 0, len(attempts)-1; i < j; i, j = i+1, j-1 {
+		attempts[i], attempts[j] = attempts[j], attempts[i]
+	}
+	return attempts, nil
+}
+
+func (s *SQLiteStore) LoadNotes() (map[string]string, error) {
+	rows, err := s.db.Query("SELECT exercise_id, text FROM notes")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notes := map[string]string{}
+	for rows.Next() {
+		var id, text string
+		if err := rows.Scan(&id, &text); err != nil {
+			return nil, err
+		}
+		notes[id] = text
+	}
+	return notes, rows.Err()
+}
+
+func (s *SQLiteStore) SaveNote(exerciseID, text string) error {
+	if text == "" {
+		_, err := s.db.Exec("DELETE FROM notes WHERE exercise_id = ?", exerciseID)
+		return err
+	}
+	_, err := s.db.Exec("INSERT INTO notes (exercise_id, text) VALUES (?, ?) "+
+		"ON CONFLICT (exercise_id) DO UPDATE SET text = excluded.text", exerciseID, text)
+	return err
+}
+
+func (s *SQLiteStore) LoadSettings() (models.Settings, error) {
+	var settings models.Settings
+	err := loadDocument(s.db, "settings", &settings)
+	return settings, err
+}
+
+func (s *SQLiteStore) SaveSettings(settings models.Settings) error {
+	return saveDocument(s.db, "settings", settings)
+}
+
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,359 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ghousemohamed/regex-in-the-terminal/models"
+)
+
+// ErrCorrupt is returned, wrapped, when stored progress can't be read and
+// no backup could stand in for it.
+var ErrCorrupt = errors.New("stored progress is corrupt")
+
+// Store is everything the app persists for one profile. Implementations
+// must make UpdateProgress atomic with respect to other writers, including
+// other processes using the same profile.
+type Store interface {
+	LoadProgress() (models.Progress, error)
+	// UpdateProgress loads the progress, applies update and saves the
+	// result as one step.
+	UpdateProgress(update func(*models.Progress)) error
+
+	// RecordAttempt appends to the attempt history, which is never
+	// rewritten. LoadAttempts returns it oldest first.
+	RecordAttempt(attempt models.Attempt) error
+	LoadAttempts() ([]models.Attempt, error)
+
+	// LoadNotes returns the learner's notes keyed by exercise ID. Saving an
+	// empty note deletes it.
+	LoadNotes() (map[string]string, error)
+	SaveNote(exerciseID, text string) error
+
+	LoadSettings() (models.Settings, error)
+	SaveSettings(settings models.Settings) error
+
+	Close() error
+}
+
+// historyQuerier is implemented by stores that can filter the attempt
+// history themselves rather than load all of it.
+type historyQuerier interface {
+	History(q HistoryQuery) ([]models.Attempt, error)
+}
+
+const (
+	BackendJSON   = "json"
+	BackendSQLite = "sqlite"
+)
+
+// Open opens the named profile with the given backend, creating the
+// profile if needed. An empty backend picks SQLite when the profile already
+// has a database and JSON files otherwise. A new database starts with a copy
+// of whatever the profile's JSON files hold.
+func Open(profile, backend string) (Store, error) {
+	if err := ValidateProfileName(profile); err != nil {
+		return nil, err
+	}
+
+	dir := ProfileDir(profile)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	if err := withLock(filepath.Join(dir, ".lock"), func() error {
+		return migrateLegacyFiles(profile, dir)
+	}); err != nil {
+		return nil, err
+	}
+
+	if backend == "" {
+		backend = BackendJSON
+		if _, err := os.Stat(filepath.Join(dir, sqliteFileName)); err == nil {
+			backend = BackendSQLite
+		}
+	}
+
+	switch backend {
+	case BackendJSON:
+		return OpenJSON(dir)
+	case BackendSQLite:
+		path := filepath.Join(dir, sqliteFileName)
+		_, statErr := os.Stat(path)
+		db, err := OpenSQLite(path)
+		if err != nil || !os.IsNotExist(statErr) {
+			return db, err
+		}
+		files, err := OpenJSON(dir)
+		if err == nil {
+			err = Copy(db, files)
+		}
+		if err != nil {
+			db.Close()
+			os.Remove(path)
+			return nil, fmt.Errorf("importing JSON files into %s: %w", path, err)
+		}
+		return db, nil
+	}
+	return nil, fmt.Errorf("unknown storage backend %q (want %q or %q)", backend, BackendJSON, BackendSQLite)
+}
+
+// Copy adds everything in src to dst, replacing dst's progress and
+// settings and appending to its history.
+func Copy(dst, src Store) error {
+	progress, err := src.LoadProgress()
+	if err != nil {
+		return err
+	}
+	if err := dst.UpdateProgress(func(p *models.Progress) { *p = progress }); err != nil {
+		return err
+	}
+
+	attempts, err := src.LoadAttempts()
+	if err != nil {
+		return err
+	}
+	for _, a := range attempts {
+		if err := dst.RecordAttempt(a); err != nil {
+			return err
+		}
+	}
+
+	notes, err := src.LoadNotes()
+	if err != nil {
+		return err
+	}
+	for id, text := range notes {
+		if err := dst.SaveNote(id, text); err != nil {
+			return err
+		}
+	}
+
+	settings, err := src.LoadSettings()
+	if err != nil {
+		return err
+	}
+	return dst.SaveSettings(settings)
+}
+
+// Completions returns the completed lessons and practice problems, listed
+// as Progress lists them.
+func Completions(lessons []models.Lesson, practices []models.PracticeProblem) models.Progress {
+	var progress models.Progress
+	for i, l := range lessons {
+		if l.Completed {
+			progress.Completed = append(progress.Completed, fmt.Sprintf("%d", i))
+		}
+	}
+	for i, p := range practices {
+		if p.Completed {
+			progress.CompletedPractice = append(progress.CompletedPractice, fmt.Sprintf("%d", i))
+		}
+	}
+	return progress
+}
+
+// SaveProgress stores where the learner is, and adds the lessons and
+// practice problems completed since saved, the progress as the caller last
+// loaded or saved it. Adding only those keeps whatever another running
+// instance stored in the meantime, including a reset.
+func SaveProgress(s Store, current int, practiceIndex int, lessons []models.Lesson, practices []models.PracticeProblem, saved models.Progress) error {
+	now := Completions(lessons, practices)
+	return s.UpdateProgress(func(progress *models.Progress) {
+		progress.CurrentLesson = current
+		progress.Completed = unionIDs(progress.Completed, newIDs(now.Completed, saved.Completed))
+		progress.PracticeIndex = practiceIndex
+		progress.CompletedPractice = unionIDs(progress.CompletedPractice, newIDs(now.CompletedPractice, saved.CompletedPractice))
+	})
+}
+
+// newIDs returns the IDs in ids that aren't in old.
+func newIDs(ids, old []string) []string {
+	seen := map[string]bool{}
+	for _, id := range old {
+		seen[id] = true
+	}
+	var added []string
+	for _, id := range ids {
+		if !seen[id] {
+			added = append(added, id)
+		}
+	}
+	return added
+}
+
+// unionIDs merges two lists of numeric IDs into one sorted list without
+// duplicates.
+func unionIDs(a, b []string) []string {
+	seen := map[string]bool{}
+	var ids []string
+	for _, id := range append(append([]string(nil), a...), b...) {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		x, _ := strconv.Atoi(ids[i])
+		y, _ := strconv.Atoi(ids[j])
+		return x < y
+	})
+	return ids
+}
+
+// ClearSpecificProgress resets every lesson ("learning") or every practice
+// problem ("practice"). Like Reset, it can be undone with UndoReset.
+func ClearSpecificProgress(s Store, clearType string) error {
+	description := "all lessons"
+	if clearType == "practice" {
+		description = "all practice problems"
+	}
+	return Reset(s, clearType, -1, "", description)
+}
+
+// SaveReview stores the review schedule of a single exercise.
+func SaveReview(s Store, exerciseID string, item models.ReviewItem) error {
+	return s.UpdateProgress(func(progress *models.Progress) {
+		if progress.Reviews == nil {
+			progress.Reviews = map[string]models.ReviewItem{}
+		}
+		progress.Reviews[exerciseID] = item
+	})
+}
+
+// RecordHint counts one hint shown for an exercise.
+func RecordHint(s Store, exerciseID string) error {
+	return s.UpdateProgress(func(progress *models.Progress) {
+		if progress.HintsUsed == nil {
+			progress.HintsUsed = map[string]int{}
+		}
+		progress.HintsUsed[exerciseID]++
+	})
+}
+
+// SaveSession stores where to resume next time, and the drafts that
+// changed since saved, the drafts as the caller last loaded or saved them.
+// A draft in saved but not in drafts has been cleared. The drafts of other
+// exercises are left alone, as another running instance may have saved
+// them.
+func SaveSession(s Store, session models.Session, drafts, saved map[string]string) error {
+	return s.UpdateProgress(func(progress *models.Progress) {
+		progress.Session = session
+		if progress.Drafts == nil {
+			progress.Drafts = map[string]string{}
+		}
+		for id, draft := range drafts {
+			if draft != saved[id] {
+				progress.Drafts[id] = draft
+			}
+		}
+		for id := range saved {
+			if _, ok := drafts[id]; !ok {
+				delete(progress.Drafts, id)
+			}
+		}
+	})
+}
+
+// AwardBadges records the achievements with the given IDs as earned at
+// now, keeping the original date of any already earned.
+func AwardBadges(s Store, ids []string, now time.Time) error {
+	return s.UpdateProgress(func(progress *models.Progress) {
+		if progress.Badges == nil {
+			progress.Badges = map[string]time.Time{}
+		}
+		for _, id := range ids {
+			if _, ok := progress.Badges[id]; !ok {
+				progress.Badges[id] = now
+			}
+		}
+	})
+}
+
+// RecordSolution keeps pattern as the solution of an exercise if it is the
+// first or shortest one that passed.
+func RecordSolution(s Store, exerciseID, pattern string) error {
+	return s.UpdateProgress(func(progress *models.Progress) {
+		if progress.Solved == nil {
+			progress.Solved = map[string]string{}
+		}
+		if betterSolution(pattern, progress.Solved[exerciseID]) {
+			progress.Solved[exerciseID] = pattern
+		}
+	})
+}
+
+// betterSolution reports whether pattern beats best, the shortest solution
+// so far. Equal lengths are settled alphabetically so that merging is
+// independent of order.
+func betterSolution(pattern, best string) bool {
+	n, bestN := utf8.RuneCountInString(pattern), utf8.RuneCountInString(best)
+	if best == "" || n < bestN {
+		return true
+	}
+	return n == bestN && pattern < best
+}
+
+// HistoryQuery selects attempts from the history. Zero fields match
+// everything.
+type HistoryQuery struct {
+	// ExerciseID matches exactly, or by prefix when it ends in "/", so
+	// "lesson/" selects every lesson.
+	ExerciseID string
+	FailedOnly bool
+	Since      time.Time
+	// Limit keeps only the most recent attempts when positive.
+	Limit int
+}
+
+func (q HistoryQuery) matches(a models.Attempt) bool {
+	if q.ExerciseID != "" && !matchesExercise(q.ExerciseID, a.ExerciseID) {
+		return false
+	}
+	if q.FailedOnly && a.Passed {
+		return false
+	}
+	if !q.Since.IsZero() && a.Time.Before(q.Since) {
+		return false
+	}
+	return true
+}
+
+// matchesExercise reports whether id is pattern, or starts with it when
+// pattern ends in "/".
+func matchesExercise(pattern, id string) bool {
+	if strings.HasSuffix(pattern, "/") {
+		return strings.HasPrefix(id, pattern)
+	}
+	return id == pattern
+}
+
+// History returns the attempts matching q, oldest first.
+func History(s Store, q HistoryQuery) ([]models.Attempt, error) {
+	if hq, ok := s.(historyQuerier); ok {
+		return hq.History(q)
+	}
+
+	attempts, err := s.LoadAttempts()
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []models.Attempt
+	for _, a := range attempts {
+		if q.matches(a) {
+			matched = append(matched, a)
+		}
+	}
+
+	if q.Limit > 0 && len(matched) > q.Limit {
+		matched = matched[len(matched)-q.Limit:]
+	}
+	return matched, nil
+}
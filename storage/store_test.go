@@ -0,0 +1,519 @@
+package storage
+
+import (
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/ghousemohamed/regex-in-the-terminal/models"
+)
+
+// eachStore runs test against a new, empty store of every kind.
+func eachStore(t *testing.T, test func(t *testing.T, s Store)) {
+	t.Helper()
+	backends := []struct {
+		name string
+		open func(t *testing.T) (Store, error)
+	}{
+		{"json", func(t *testing.T) (Store, error) { return OpenJSON(t.TempDir()) }},
+		{"memory", func(t *testing.T) (Store, error) { return NewMemoryStore(), nil }},
+		{"sqlite", func(t *testing.T) (Store, error) {
+			return OpenSQLite(filepath.Join(t.TempDir(), sqliteFileName))
+		}},
+	}
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			s, err := b.open(t)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { s.Close() })
+			test(t, s)
+		})
+	}
+}
+
+func mustUpdate(t *testing.T, s Store, update func(*models.Progress)) {
+	t.Helper()
+	if err := s.UpdateProgress(update); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func mustLoad(t *testing.T, s Store) models.Progress {
+	t.Helper()
+	progress, err := s.LoadProgress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return progress
+}
+
+func patterns(attempts []models.Attempt) []string {
+	var p []string
+	for _, a := range attempts {
+		p = append(p, a.Pattern)
+	}
+	return p
+}
+
+func TestHistory(t *testing.T) {
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	recorded := []models.Attempt{
+		{ExerciseID: "lesson/dot", Pattern: "l1", Passed: false},
+		{ExerciseID: "lesson/dot", Pattern: "l2", Passed: true},
+		{ExerciseID: "practice/email", Pattern: "p1", Passed: false},
+		{ExerciseID: "lesson/dotall", Pattern: "l3", Passed: false},
+		{ExerciseID: "practice/email", Pattern: "p2", Passed: true},
+	}
+	for i := range recorded {
+		recorded[i].Time = start.Add(time.Duration(i) * time.Hour)
+	}
+
+	tests := []struct {
+		name string
+		q    HistoryQuery
+		want []string
+	}{
+		{"everything, oldest first", HistoryQuery{}, []string{"l1", "l2", "p1", "l3", "p2"}},
+		{"one exercise", HistoryQuery{ExerciseID: "lesson/dot"}, []string{"l1", "l2"}},
+		{"prefix", HistoryQuery{ExerciseID: "lesson/"}, []string{"l1", "l2", "l3"}},
+		{"failed only", HistoryQuery{FailedOnly: true}, []string{"l1", "p1", "l3"}},
+		{"since", HistoryQuery{Since: start.Add(2 * time.Hour)}, []string{"p1", "l3", "p2"}},
+		{"limit keeps the most recent", HistoryQuery{Limit: 2}, []string{"l3", "p2"}},
+		{"limit above the count", HistoryQuery{Limit: 10}, []string{"l1", "l2", "p1", "l3", "p2"}},
+		{"combined", HistoryQuery{ExerciseID: "lesson/", FailedOnly: true, Limit: 1}, []string{"l3"}},
+		{"no match", HistoryQuery{ExerciseID: "practice/none"}, nil},
+	}
+
+	eachStore(t, func(t *testing.T, s Store) {
+		for _, a := range recorded {
+			if err := s.RecordAttempt(a); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got, err := History(s, tt.q)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(patterns(got), tt.want) {
+					t.Errorf("History(%+v) = %v, want %v", tt.q, patterns(got), tt.want)
+				}
+			})
+		}
+	})
+}
+
+func TestNotes(t *testing.T) {
+	eachStore(t, func(t *testing.T, s Store) {
+		steps := []struct {
+			id, text string
+			want     map[string]string
+		}{
+			{"lesson/dot", "any character", map[string]string{"lesson/dot": "any character"}},
+			{"practice/email", "use \\w", map[string]string{"lesson/dot": "any character", "practice/email": "use \\w"}},
+			{"lesson/dot", "but not \\n", map[string]string{"lesson/dot": "but not \\n", "practice/email": "use \\w"}},
+			{"lesson/dot", "", map[string]string{"practice/email": "use \\w"}},
+		}
+		for _, step := range steps {
+			if err := s.SaveNote(step.id, step.text); err != nil {
+				t.Fatal(err)
+			}
+			got, err := s.LoadNotes()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, step.want) {
+				t.Errorf("after saving %q for %s, notes = %v, want %v", step.text, step.id, got, step.want)
+			}
+		}
+	})
+}
+
+func TestRecordHint(t *testing.T) {
+	eachStore(t, func(t *testing.T, s Store) {
+		for _, id := range []string{"lesson/dot", "practice/email", "lesson/dot"} {
+			if err := RecordHint(s, id); err != nil {
+				t.Fatal(err)
+			}
+		}
+		want := map[string]int{"lesson/dot": 2, "practice/email": 1}
+		if got := mustLoad(t, s).HintsUsed; !reflect.DeepEqual(got, want) {
+			t.Errorf("hints used = %v, want %v", got, want)
+		}
+	})
+}
+
+func TestAwardBadges(t *testing.T) {
+	first := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	later := first.Add(48 * time.Hour)
+
+	eachStore(t, func(t *testing.T, s Store) {
+		if err := AwardBadges(s, []string{"first-steps", "first-try"}, first); err != nil {
+			t.Fatal(err)
+		}
+		if err := AwardBadges(s, []string{"first-try", "graduate"}, later); err != nil {
+			t.Fatal(err)
+		}
+
+		badges := mustLoad(t, s).Badges
+		want := map[string]time.Time{"first-steps": first, "first-try": first, "graduate": later}
+		if len(badges) != len(want) {
+			t.Fatalf("badges = %v, want %v", badges, want)
+		}
+		for id, at := range want {
+			if !badges[id].Equal(at) {
+				t.Errorf("badge %s earned at %v, want %v", id, badges[id], at)
+			}
+		}
+	})
+}
+
+func TestRecordSolution(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{`\d\d\d`, `\d\d\d`},
+		{`\d{3}`, `\d{3}`},
+		{`\d{3,}`, `\d{3}`},
+		{`[0-9]`, `[0-9]`},
+		{`\d{2}`, `[0-9]`},
+		{`\d+`, `\d+`},
+	}
+
+	eachStore(t, func(t *testing.T, s Store) {
+		for _, tt := range tests {
+			if err := RecordSolution(s, "practice/digits", tt.pattern); err != nil {
+				t.Fatal(err)
+			}
+			if got := mustLoad(t, s).Solved["practice/digits"]; got != tt.want {
+				t.Errorf("after %q the solution is %q, want %q", tt.pattern, got, tt.want)
+			}
+		}
+	})
+}
+
+func TestImport(t *testing.T) {
+	at := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	shared := models.Attempt{ExerciseID: "lesson/dot", Pattern: "a.c", Passed: true, Time: at}
+	theirs := models.Attempt{ExerciseID: "lesson/anchors", Pattern: "^a$", Passed: true, Time: at.Add(time.Hour)}
+
+	bundle := Bundle{
+		Version:  BundleVersion,
+		Progress: models.Progress{CurrentLesson: 4, Completed: []string{"1", "3"}},
+		Attempts: []models.Attempt{shared, theirs},
+		Solved:   map[string]string{"lesson/dot": "a.c", "lesson/anchors": "^a$"},
+		Notes:    map[string]string{"lesson/dot": "their note", "lesson/anchors": "anchors note"},
+	}
+
+	tests := []struct {
+		name          string
+		replace       bool
+		wantCurrent   int
+		wantCompleted []string
+		wantSolved    map[string]string
+		wantNotes     map[string]string
+		wantResult    ImportResult
+	}{
+		{
+			name:          "merge",
+			wantCurrent:   2,
+			wantCompleted: []string{"0", "1", "3"},
+			wantSolved:    map[string]string{"lesson/dot": "a..", "lesson/anchors": "^a$", "lesson/classes": "[ab]"},
+			wantNotes:     map[string]string{"lesson/dot": "my note", "lesson/anchors": "anchors note", "lesson/classes": "classes note"},
+			wantResult:    ImportResult{Attempts: 1, Solved: 1, Notes: 1},
+		},
+		{
+			name:          "replace",
+			replace:       true,
+			wantCurrent:   4,
+			wantCompleted: []string{"1", "3"},
+			wantSolved:    map[string]string{"lesson/dot": "a.c", "lesson/anchors": "^a$"},
+			wantNotes:     map[string]string{"lesson/dot": "their note", "lesson/anchors": "anchors note"},
+			wantResult:    ImportResult{Attempts: 1, Solved: 2, Notes: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eachStore(t, func(t *testing.T, s Store) {
+				mustUpdate(t, s, func(p *models.Progress) {
+					p.CurrentLesson = 2
+					p.Completed = []string{"0", "1"}
+					p.Solved = map[string]string{"lesson/dot": "a..", "lesson/classes": "[ab]"}
+				})
+				if err := s.RecordAttempt(shared); err != nil {
+					t.Fatal(err)
+				}
+				for id, text := range map[string]string{"lesson/dot": "my note", "lesson/classes": "classes note"} {
+					if err := s.SaveNote(id, text); err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				result, err := Import(s, bundle, tt.replace)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if result != tt.wantResult {
+					t.Errorf("result = %+v, want %+v", result, tt.wantResult)
+				}
+
+				progress := mustLoad(t, s)
+				if progress.CurrentLesson != tt.wantCurrent {
+					t.Errorf("current lesson = %d, want %d", progress.CurrentLesson, tt.wantCurrent)
+				}
+				if !reflect.DeepEqual(progress.Completed, tt.wantCompleted) {
+					t.Errorf("completed = %v, want %v", progress.Completed, tt.wantCompleted)
+				}
+				if !reflect.DeepEqual(progress.Solved, tt.wantSolved) {
+					t.Errorf("solved = %v, want %v", progress.Solved, tt.wantSolved)
+				}
+				notes, err := s.LoadNotes()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(notes, tt.wantNotes) {
+					t.Errorf("notes = %v, want %v", notes, tt.wantNotes)
+				}
+
+				// The attempt both sides have is only stored once, and
+				// importing again adds no attempts.
+				attempts, err := s.LoadAttempts()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if want := []string{"a.c", "^a$"}; !reflect.DeepEqual(patterns(attempts), want) {
+					t.Errorf("attempts = %v, want %v", patterns(attempts), want)
+				}
+				again, err := Import(s, bundle, tt.replace)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if again.Attempts != 0 {
+					t.Errorf("importing twice added %d attempts", again.Attempts)
+				}
+			})
+		})
+	}
+}
+
+func TestResetAndUndo(t *testing.T) {
+	reviewed := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	before := models.Progress{
+		CurrentLesson:     1,
+		Completed:         []string{"0", "1"},
+		PracticeIndex:     2,
+		CompletedPractice: []string{"2"},
+		Reviews: map[string]models.ReviewItem{
+			"lesson/dot":     {Interval: 6, LastReviewed: reviewed},
+			"lesson/anchors": {Interval: 1, LastReviewed: reviewed},
+			"practice/email": {Interval: 1, LastReviewed: reviewed},
+		},
+		Solved: map[string]string{
+			"lesson/dot":     "a.c",
+			"lesson/anchors": "^a",
+			"practice/email": `\w+@\w+`,
+		},
+		Badges: map[string]time.Time{"graduate": reviewed},
+	}
+
+	tests := []struct {
+		name      string
+		clearType string
+		index     int
+		id        string
+		// what is left after the reset
+		current   int
+		completed []string
+		practices []string
+		reviews   []string
+		solved    []string
+	}{
+		{
+			name: "one lesson", clearType: "learning", index: 0, id: "lesson/dot",
+			current: 1, completed: []string{"1"}, practices: []string{"2"},
+			reviews: []string{"lesson/anchors", "practice/email"},
+			solved:  []string{"lesson/anchors", "practice/email"},
+		},
+		{
+			name: "all lessons", clearType: "learning", index: -1,
+			current: 0, practices: []string{"2"},
+			reviews: []string{"practice/email"},
+			solved:  []string{"practice/email"},
+		},
+		{
+			name: "one problem", clearType: "practice", index: 2, id: "practice/email",
+			current: 1, completed: []string{"0", "1"},
+			reviews: []string{"lesson/anchors", "lesson/dot"},
+			solved:  []string{"lesson/anchors", "lesson/dot"},
+		},
+	}
+
+	keys := func(m any) []string {
+		var ids []string
+		for _, k := range reflect.ValueOf(m).MapKeys() {
+			ids = append(ids, k.String())
+		}
+		sort.Strings(ids)
+		return ids
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eachStore(t, func(t *testing.T, s Store) {
+				mustUpdate(t, s, func(p *models.Progress) { *p = before })
+
+				if err := Reset(s, tt.clearType, tt.index, tt.id, "test reset"); err != nil {
+					t.Fatal(err)
+				}
+				if err := RevokeBadges(s, []string{"graduate"}); err != nil {
+					t.Fatal(err)
+				}
+
+				after := mustLoad(t, s)
+				if after.CurrentLesson != tt.current {
+					t.Errorf("current lesson = %d, want %d", after.CurrentLesson, tt.current)
+				}
+				if !reflect.DeepEqual(after.Completed, tt.completed) {
+					t.Errorf("completed = %v, want %v", after.Completed, tt.completed)
+				}
+				if !reflect.DeepEqual(after.CompletedPractice, tt.practices) {
+					t.Errorf("completed practice = %v, want %v", after.CompletedPractice, tt.practices)
+				}
+				if got := keys(after.Reviews); !reflect.DeepEqual(got, tt.reviews) {
+					t.Errorf("reviews = %v, want %v", got, tt.reviews)
+				}
+				if got := keys(after.Solved); !reflect.DeepEqual(got, tt.solved) {
+					t.Errorf("solved = %v, want %v", got, tt.solved)
+				}
+				if len(after.Badges) != 0 {
+					t.Errorf("badges = %v, want none", after.Badges)
+				}
+
+				snapshot, err := UndoReset(s)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if snapshot.Description != "test reset" {
+					t.Errorf("undid %q, want %q", snapshot.Description, "test reset")
+				}
+				undone := mustLoad(t, s)
+				if undone.CurrentLesson != before.CurrentLesson ||
+					!reflect.DeepEqual(undone.Completed, before.Completed) ||
+					!reflect.DeepEqual(undone.CompletedPractice, before.CompletedPractice) ||
+					!reflect.DeepEqual(undone.Solved, before.Solved) ||
+					!reflect.DeepEqual(keys(undone.Reviews), keys(before.Reviews)) ||
+					!reflect.DeepEqual(keys(undone.Badges), keys(before.Badges)) {
+					t.Errorf("after undo progress = %+v, want %+v", undone, before)
+				}
+
+				if _, err := UndoReset(s); !errors.Is(err, ErrNothingToUndo) {
+					t.Errorf("second undo = %v, want ErrNothingToUndo", err)
+				}
+			})
+		})
+	}
+}
+
+func TestUndoResetKeepsNewerProgress(t *testing.T) {
+	eachStore(t, func(t *testing.T, s Store) {
+		mustUpdate(t, s, func(p *models.Progress) {
+			p.Completed = []string{"0"}
+			p.Solved = map[string]string{"lesson/dot": "a.c"}
+		})
+		if err := Reset(s, "learning", -1, "", "all lessons"); err != nil {
+			t.Fatal(err)
+		}
+
+		// Solved again after the reset, once more briefly.
+		mustUpdate(t, s, func(p *models.Progress) { p.Completed = []string{"2"} })
+		if err := RecordSolution(s, "lesson/dot", "a.."); err != nil {
+			t.Fatal(err)
+		}
+		if err := RecordSolution(s, "lesson/dot", "..."); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := UndoReset(s); err != nil {
+			t.Fatal(err)
+		}
+		progress := mustLoad(t, s)
+		if want := []string{"0", "2"}; !reflect.DeepEqual(progress.Completed, want) {
+			t.Errorf("completed = %v, want %v", progress.Completed, want)
+		}
+		// Equal lengths are settled alphabetically.
+		if got := progress.Solved["lesson/dot"]; got != "..." {
+			t.Errorf("solution = %q, want %q", got, "...")
+		}
+	})
+}
+
+func TestLoadProgressCopiesResetSnapshot(t *testing.T) {
+	eachStore(t, func(t *testing.T, s Store) {
+		mustUpdate(t, s, func(p *models.Progress) {
+			p.Solved = map[string]string{"lesson/dot": "a.c"}
+			p.Badges = map[string]time.Time{"graduate": time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)}
+		})
+		if err := Reset(s, "learning", -1, "", "all lessons"); err != nil {
+			t.Fatal(err)
+		}
+		if err := RevokeBadges(s, []string{"graduate"}); err != nil {
+			t.Fatal(err)
+		}
+
+		loaded := mustLoad(t, s)
+		want := *loaded.LastReset
+		want.Solved = map[string]string{"lesson/dot": "a.c"}
+		want.Badges = maps.Clone(loaded.LastReset.Badges)
+
+		loaded.LastReset.Solved["lesson/dot"] = "changed"
+		delete(loaded.LastReset.Badges, "graduate")
+		loaded.LastReset.Reviews = map[string]models.ReviewItem{"lesson/dot": {}}
+
+		if got := *mustLoad(t, s).LastReset; !reflect.DeepEqual(got, want) {
+			t.Errorf("after changing a loaded copy, snapshot = %+v, want %+v", got, want)
+		}
+	})
+}
+
+func TestJSONTornHistoryLine(t *testing.T) {
+	s, err := OpenJSON(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	at := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	if err := s.RecordAttempt(models.Attempt{ExerciseID: "lesson/dot", Pattern: "a", Time: at}); err != nil {
+		t.Fatal(err)
+	}
+	// A crash cut the next append short.
+	f, err := os.OpenFile(s.historyFile, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(`{"exercise_id":"lesson/dot","pat`); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	if err := s.RecordAttempt(models.Attempt{ExerciseID: "lesson/dot", Pattern: "a.c", Passed: true, Time: at}); err != nil {
+		t.Fatal(err)
+	}
+	attempts, err := s.LoadAttempts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(attempts) != 2 || attempts[0].Pattern != "a" || attempts[1].Pattern != "a.c" {
+		t.Errorf("attempts = %+v, want the one before and the one after the torn line", attempts)
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"os"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/ghousemohamed/regex-in-the-terminal/regexinput"
+	"github.com/ghousemohamed/regex-in-the-terminal/theme"
+)
+
+var (
+	matchStyle        lipgloss.Style
+	currentMatchStyle lipgloss.Style
+	patternStyles     regexinput.Styles
+)
+
+func init() {
+	applyTheme(theme.Builtin[theme.Default])
+}
+
+// noColor reports whether the NO_COLOR convention (https://no-color.org)
+// asks for no colors. lipgloss already drops the colors themselves; this is
+// for drawing that makes no sense without them, like gradients.
+func noColor() bool {
+	return os.Getenv("NO_COLOR") != ""
+}
+
+// applyTheme sets every style from the colors of t.
+func applyTheme(t theme.Theme) {
+	color := func(c string) lipgloss.Color { return lipgloss.Color(c) }
+
+	borderColor = color(t.Border)
+	successColor = color(t.Success)
+	gradientColors = t.Gradient
+
+	titleStyle = lipgloss.NewStyle().
+		Bold(true).
+		Foreground(color(t.AccentText)).
+		Background(color(t.Accent)).
+		Padding(0, 1).
+		MarginBottom(1)
+
+	lessonStyle = lipgloss.NewStyle().
+		Foreground(color(t.Text)).
+		PaddingLeft(1)
+
+	tocStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(borderColor).
+		Padding(1, 2).
+		MarginLeft(2)
+
+	completedStyle = lipgloss.NewStyle().
+		Foreground(successColor)
+
+	lockedStyle = lipgloss.NewStyle().
+		Foreground(color(t.Faint)).
+		Faint(true)
+
+	tagStyle = lipgloss.NewStyle().
+		Foreground(color(t.Tag)).
+		Italic(true)
+
+	incompletedStyle = lipgloss.NewStyle().
+		Foreground(color(t.Muted))
+
+	selectedStyle = lipgloss.NewStyle().
+		Bold(true).
+		Foreground(color(t.Accent))
+
+	errorStyle = lipgloss.NewStyle().
+		Foreground(color(t.Error))
+
+	successStyle = lipgloss.NewStyle().
+		Foreground(successColor)
+
+	hintStyle = lipgloss.NewStyle().
+		Foreground(color(t.Warning))
+
+	// Underlined too, so the match still shows without colors.
+	matchStyle = lipgloss.NewStyle().
+		Foreground(color(t.AccentText)).
+		Background(color(t.Accent)).
+		Underline(true)
+	// Bold as well, for when the colors are the same or missing.
+	currentMatchStyle = lipgloss.NewStyle().
+		Foreground(color(t.AccentText)).
+		Background(color(t.HeaderBorder)).
+		Bold(true).
+		Underline(true)
+
+	// Bold for everything but literals, so the syntax still stands out
+	// without colors.
+	patternStyles = regexinput.Styles{
+		Literal:    lipgloss.NewStyle().Foreground(color(t.Text)),
+		Escape:     lipgloss.NewStyle().Foreground(color(t.Text)).Bold(true),
+		Meta:       lipgloss.NewStyle().Foreground(color(t.Meta)).Bold(true),
+		Class:      lipgloss.NewStyle().Foreground(successColor).Bold(true),
+		Quantifier: lipgloss.NewStyle().Foreground(color(t.Warning)).Bold(true),
+		Group:      lipgloss.NewStyle().Foreground(color(t.HeaderBorder)).Bold(true),
+		Anchor:     lipgloss.NewStyle().Foreground(color(t.Tag)).Bold(true),
+		MatchingParen: lipgloss.NewStyle().
+			Foreground(color(t.AccentText)).
+			Background(color(t.Accent)),
+		Invalid: lipgloss.NewStyle().
+			Foreground(color(t.Error)).
+			Underline(true),
+	}
+
+	headerStyle = lipgloss.NewStyle().
+		Bold(true).
+		Padding(1, 2).
+		Align(lipgloss.Center).
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(color(t.HeaderBorder))
+
+	mainContentStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(color(t.Accent)).
+		Padding(1, 2).
+		MarginRight(2)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/ghousemohamed/regex-in-the-terminal/models"
+)
+
+// caseResult is how a submitted pattern fared on one test case.
+type caseResult struct {
+	testCase models.TestCase
+	passed   bool
+	// match holds the submatch indexes of the leftmost match, or is nil
+	// when the pattern doesn't match.
+	match []int
+}
+
+// caseCheck is every test case of the exercise on screen run against the
+// last pattern submitted, shown after a failed attempt.
+type caseCheck struct {
+	// names are the capture group names of the pattern, as returned by
+	// regexp.Regexp.SubexpNames.
+	names   []string
+	results []caseResult
+	// selected is the test case whose match details are shown, or -1.
+	selected int
+}
+
+// checkCases runs pattern against every test case. It returns an empty
+// check if the pattern doesn't compile, as there is nothing to show.
+func checkCases(pattern string, testCases []models.TestCase) caseCheck {
+	check := caseCheck{selected: -1}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return check
+	}
+	check.names = re.SubexpNames()
+	for _, tc := range testCases {
+		match := re.FindStringSubmatchIndex(tc.Text)
+		check.results = append(check.results, caseResult{
+			testCase: tc,
+			passed:   (match != nil) == tc.Expected,
+			match:    match,
+		})
+	}
+	return check
+}
+
+// caseLinePattern finds the test case number in a line of the rendered
+// list, so a click can be mapped back to it.
+var caseLinePattern = regexp.MustCompile(`[✓✗] case (\d+):`)
+
+// withCases adds the test case list, and the details of the selected one,
+// below the left column.
+func (m model) withCases(leftCol string) string {
+	if len(m.cases.results) == 0 {
+		return leftCol
+	}
+
+	var list strings.Builder
+	list.WriteString(incompletedStyle.Render("Test cases · click one for details"))
+	for i, r := range m.cases.results {
+		mark, style := "✓", successStyle
+		if !r.passed {
+			mark, style = "✗", errorStyle
+		}
+		expect := "should match"
+		if !r.testCase.Expected {
+			expect = "shouldn't match"
+		}
+		line := fmt.Sprintf("%s case %d: %s '%s'", mark, i+1, expect, r.testCase.Text)
+		if i == m.cases.selected {
+			style = style.Bold(true)
+		}
+		list.WriteString("\n" + style.Render(line))
+	}
+
+	if m.cases.selected >= 0 {
+		list.WriteString("\n\n" + m.caseDetails(m.cases.results[m.cases.selected]))
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left,
+		leftCol,
+		lipgloss.NewStyle().
+			PaddingLeft(2).
+			PaddingTop(1).
+			Width(lipgloss.Width(leftCol)).
+			Render(list.String()),
+	)
+}
+
+// caseDetails shows what the pattern matched in a test case: the text with
+// the match highlighted, where the match is, and what each group captured.
+func (m model) caseDetails(r caseResult) string {
+	text := r.testCase.Text
+	if r.match == nil {
+		return lessonStyle.Render(fmt.Sprintf("'%s'\nno match", text))
+	}
+
+	start, end := r.match[0], r.match[1]
+	lines := []string{
+		"'" + text[:start] + matchStyle.Render(text[start:end]) + text[end:] + "'",
+		fmt.Sprintf("matched '%s' at %d–%d", text[start:end], start, end),
+	}
+	for g := 1; g < len(m.cases.names); g++ {
+		name := fmt.Sprintf("group %d", g)
+		if m.cases.names[g] != "" {
+			name += " (" + m.cases.names[g] + ")"
+		}
+		if r.match[2*g] < 0 {
+			lines = append(lines, name+": no match")
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s: '%s'", name, text[r.match[2*g]:r.match[2*g+1]]))
+	}
+	return lessonStyle.Render(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,173 @@
+package theme
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Default is the theme used when none is configured.
+const Default = "dark"
+
+// Theme is a set of colors, each a hex string like "#7B2CBF" or an ANSI
+// color number like "5".
+type Theme struct {
+	// Base names the built-in theme a custom theme starts from; the colors
+	// it sets replace those of the base. Defaults to Default.
+	Base string `json:"base,omitempty"`
+
+	// Accent marks the selection and the lesson title, which is drawn in
+	// AccentText on an Accent background.
+	Accent     string `json:"accent,omitempty"`
+	AccentText string `json:"accent_text,omitempty"`
+	// Text is the lesson and problem text.
+	Text string `json:"text,omitempty"`
+	// Muted is secondary text such as instructions and unselected options;
+	// Faint is for locked lessons and Tag for concept tags.
+	Muted string `json:"muted,omitempty"`
+	Faint string `json:"faint,omitempty"`
+	Tag   string `json:"tag,omitempty"`
+	// Border is drawn around boxes, HeaderBorder around the title bar.
+	Border       string `json:"border,omitempty"`
+	HeaderBorder string `json:"header_border,omitempty"`
+	Success      string `json:"success,omitempty"`
+	Error        string `json:"error,omitempty"`
+	// Warning colors hints and quantifiers in the pattern.
+	Warning string `json:"warning,omitempty"`
+	// Meta colors . and | in the pattern.
+	Meta string `json:"meta,omitempty"`
+	// Gradient colors headings from left to right.
+	Gradient []string `json:"gradient,omitempty"`
+}
+
+// Builtin lists the themes that ship with learn-regex, by name.
+var Builtin = map[string]Theme{
+	"dark": {
+		Accent:       "#7B2CBF",
+		AccentText:   "#FAFAFA",
+		Text:         "#4A5568",
+		Muted:        "#6B7280",
+		Faint:        "#4B5563",
+		Tag:          "#9CA3AF",
+		Border:       "#874BFD",
+		HeaderBorder: "#FF0080",
+		Success:      "#10B981",
+		Error:        "#E11D48",
+		Warning:      "#F59E0B",
+		Meta:         "#A78BFA",
+		Gradient:     []string{"#FF0080", "#FF359A", "#FF65B5", "#FF94D0", "#FFC2EB"},
+	},
+	"light": {
+		Accent:       "#6D28D9",
+		AccentText:   "#FFFFFF",
+		Text:         "#1F2937",
+		Muted:        "#4B5563",
+		Faint:        "#9CA3AF",
+		Tag:          "#6B7280",
+		Border:       "#7C3AED",
+		HeaderBorder: "#BE185D",
+		Success:      "#047857",
+		Error:        "#B91C1C",
+		Warning:      "#B45309",
+		Meta:         "#6D28D9",
+		Gradient:     []string{"#BE185D", "#A21CAF", "#7E22CE", "#6D28D9", "#4338CA"},
+	},
+	"high-contrast": {
+		Accent:       "#FFFF00",
+		AccentText:   "#000000",
+		Text:         "#FFFFFF",
+		Muted:        "#FFFFFF",
+		Faint:        "#C0C0C0",
+		Tag:          "#00FFFF",
+		Border:       "#FFFFFF",
+		HeaderBorder: "#FFFFFF",
+		Success:      "#00FF00",
+		Error:        "#FF5555",
+		Warning:      "#FFFF00",
+		Meta:         "#00FFFF",
+		Gradient:     []string{"#FFFFFF"},
+	},
+	// colorblind-safe uses the Okabe-Ito palette, which keeps success and
+	// error apart for red-green colorblindness: blue against vermillion.
+	"colorblind-safe": {
+		Accent:       "#0072B2",
+		AccentText:   "#FFFFFF",
+		Text:         "#D0D0D0",
+		Muted:        "#A0A0A0",
+		Faint:        "#707070",
+		Tag:          "#56B4E9",
+		Border:       "#0072B2",
+		HeaderBorder: "#CC79A7",
+		Success:      "#56B4E9",
+		Error:        "#D55E00",
+		Warning:      "#F0E442",
+		Meta:         "#CC79A7",
+		Gradient:     []string{"#CC79A7", "#E69F00", "#F0E442", "#56B4E9", "#0072B2"},
+	},
+}
+
+// Names returns the names of the built-in and the custom themes, sorted.
+func Names(custom map[string]Theme) []string {
+	var names []string
+	for name := range Builtin {
+		names = append(names, name)
+	}
+	for name := range custom {
+		if _, ok := Builtin[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Resolve returns the theme called name, looking in custom before the
+// built-in themes. Colors a custom theme leaves out come from its base.
+func Resolve(name string, custom map[string]Theme) (Theme, error) {
+	if name == "" {
+		name = Default
+	}
+	t, ok := custom[name]
+	if !ok {
+		if t, ok := Builtin[name]; ok {
+			return t, nil
+		}
+		return Theme{}, fmt.Errorf("unknown theme %q; choose one of %s", name, strings.Join(Names(custom), ", "))
+	}
+
+	baseName := t.Base
+	if baseName == "" {
+		baseName = Default
+	}
+	base, ok := Builtin[baseName]
+	if !ok {
+		return Theme{}, fmt.Errorf("theme %q: unknown base theme %q", name, baseName)
+	}
+	return overlay(base, t), nil
+}
+
+// overlay returns base with the colors set in t.
+func overlay(base, t Theme) Theme {
+	pick := func(dst *string, src string) {
+		if src != "" {
+			*dst = src
+		}
+	}
+	pick(&base.Accent, t.Accent)
+	pick(&base.AccentText, t.AccentText)
+	pick(&base.Text, t.Text)
+	pick(&base.Muted, t.Muted)
+	pick(&base.Faint, t.Faint)
+	pick(&base.Tag, t.Tag)
+	pick(&base.Border, t.Border)
+	pick(&base.HeaderBorder, t.HeaderBorder)
+	pick(&base.Success, t.Success)
+	pick(&base.Error, t.Error)
+	pick(&base.Warning, t.Warning)
+	pick(&base.Meta, t.Meta)
+	if len(t.Gradient) > 0 {
+		base.Gradient = t.Gradient
+	}
+	base.Base = ""
+	return base
+}
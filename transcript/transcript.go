@@ -0,0 +1,341 @@
+package transcript
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+
+	"github.com/ghousemohamed/regex-in-the-terminal/models"
+)
+
+// Marker starts the signature line at the end of a signed transcript. It is
+// followed by the Ed25519 signature, in hex, of everything before that
+// line.
+//
+// Only the holder of the signing key can make the signature, so the key
+// must stay with whoever issues transcripts, not with the learner: a
+// learner who can read it can sign whatever they like.
+const Marker = "learn-regex-signature: ed25519-"
+
+var (
+	// ErrTampered is returned by Verify when the signature doesn't match
+	// the contents or the key.
+	ErrTampered = errors.New("transcript was modified after it was signed, or signed with another key")
+	// ErrUnsigned is returned by Verify when there is no signature line.
+	ErrUnsigned = errors.New("no signature found; only transcripts signed by the issuer can be verified")
+)
+
+// Entry is one completed exercise.
+type Entry struct {
+	ExerciseID string
+	Title      string
+	// CompletedAt is when the exercise was first solved, or the zero time
+	// when that predates the attempt history.
+	CompletedAt time.Time
+	// Attempts counts the patterns submitted up to and including the first
+	// that passed.
+	Attempts int
+	Pattern  string
+}
+
+type Transcript struct {
+	Profile      string
+	GeneratedAt  time.Time
+	TotalLessons int
+	Lessons      []Entry
+	Practices    []Entry
+}
+
+// Complete reports whether every lesson has been completed.
+func (t Transcript) Complete() bool {
+	return len(t.Lessons) == t.TotalLessons
+}
+
+// Build lists the completed lessons and practice problems, in course order,
+// with the details found in the attempt history. solved holds the shortest
+// passing pattern per exercise ID.
+func Build(profile string, lessons []models.Lesson, practices []models.PracticeProblem, attempts []models.Attempt, solved map[string]string, now time.Time) Transcript {
+	type firstPass struct {
+		at       time.Time
+		attempts int
+		pattern  string
+		passed   bool
+	}
+	history := map[string]*firstPass{}
+	for _, a := range attempts {
+		fp := history[a.ExerciseID]
+		if fp == nil {
+			fp = &firstPass{}
+			history[a.ExerciseID] = fp
+		}
+		if fp.passed {
+			continue
+		}
+		fp.attempts++
+		if a.Passed {
+			fp.passed = true
+			fp.at = a.Time
+			fp.pattern = a.Pattern
+		}
+	}
+
+	entry := func(id, title string) Entry {
+		e := Entry{ExerciseID: id, Title: title, Pattern: solved[id]}
+		if fp := history[id]; fp != nil && fp.passed {
+			e.CompletedAt = fp.at
+			e.Attempts = fp.attempts
+			if e.Pattern == "" {
+				e.Pattern = fp.pattern
+			}
+		}
+		return e
+	}
+
+	t := Transcript{Profile: profile, GeneratedAt: now, TotalLessons: len(lessons)}
+	for _, l := range lessons {
+		if l.Completed {
+			t.Lessons = append(t.Lessons, entry(l.ExerciseID(), l.Title))
+		}
+	}
+	for _, p := range practices {
+		if p.Completed {
+			t.Practices = append(t.Practices, entry(p.ExerciseID(), p.Title))
+		}
+	}
+	return t
+}
+
+func (e Entry) date() string {
+	if e.CompletedAt.IsZero() {
+		return "—"
+	}
+	return e.CompletedAt.Local().Format("2006-01-02")
+}
+
+func (e Entry) attempts() string {
+	if e.Attempts == 0 {
+		return "—"
+	}
+	return fmt.Sprint(e.Attempts)
+}
+
+func (t Transcript) status() string {
+	if t.Complete() {
+		return fmt.Sprintf("Completed all %d lessons", t.TotalLessons)
+	}
+	return fmt.Sprintf("In progress: %d of %d lessons completed", len(t.Lessons), t.TotalLessons)
+}
+
+// seal appends the signature line, built by footer from the signature of
+// body, or unsigned when there is no key.
+func seal(body string, key ed25519.PrivateKey, footer func(sig string) string, unsigned string) []byte {
+	if key == nil {
+		return []byte(body + unsigned)
+	}
+	return []byte(body + footer(hex.EncodeToString(ed25519.Sign(key, []byte(body)))))
+}
+
+func markdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+// Markdown renders t as a Markdown document, signed with key unless it is
+// nil.
+func Markdown(t Transcript, key ed25519.PrivateKey) []byte {
+	var b strings.Builder
+	b.WriteString("# Regex Course Transcript\n\n")
+	fmt.Fprintf(&b, "- Learner profile: %s\n", t.Profile)
+	fmt.Fprintf(&b, "- Status: %s\n", t.status())
+	fmt.Fprintf(&b, "- Generated: %s\n", t.GeneratedAt.Local().Format("2006-01-02 15:04 MST"))
+
+	section := func(title string, entries []Entry) {
+		fmt.Fprintf(&b, "\n## %s\n\n", title)
+		if len(entries) == 0 {
+			b.WriteString("None yet.\n")
+			return
+		}
+		b.WriteString("| # | Exercise | Completed | Attempts | Solution |\n")
+		b.WriteString("|---|---|---|---|---|\n")
+		for i, e := range entries {
+			solution := "—"
+			if e.Pattern != "" {
+				solution = "`" + markdownCell(e.Pattern) + "`"
+			}
+			fmt.Fprintf(&b, "| %d | %s | %s | %s | %s |\n",
+				i+1, markdownCell(e.Title), e.date(), e.attempts(), solution)
+		}
+	}
+	section("Lessons", t.Lessons)
+	section("Practice Problems", t.Practices)
+	b.WriteString("\n---\n\n")
+
+	return seal(b.String(), key, markdownFooter, "_Unsigned copy for your own records._\n")
+}
+
+// markdownFooter is the signature line of a Markdown transcript.
+func markdownFooter(sig string) string {
+	return "`" + Marker + sig + "`\n"
+}
+
+var htmlTemplate = template.Must(template.New("transcript").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Regex Course Transcript – {{.Profile}}</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 60rem; margin: 2rem auto; padding: 0 1rem; color: #1F2937; }
+h1 { color: #7B2CBF; }
+.status { font-size: 1.2rem; font-weight: bold; color: {{if .Complete}}#10B981{{else}}#6B7280{{end}}; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 2rem; }
+th, td { text-align: left; padding: 0.3rem 0.6rem; border-bottom: 1px solid #E5E7EB; }
+th { background: #F3F4F6; }
+code { font-family: ui-monospace, monospace; background: #F3F4F6; padding: 0 0.2rem; }
+footer { color: #6B7280; font-size: 0.8rem; word-break: break-all; }
+</style>
+</head>
+<body>
+<h1>Regex Course Transcript</h1>
+<p class="status">{{.Status}}</p>
+<p>Learner profile: {{.Profile}}<br>Generated: {{.Generated}}</p>
+{{range .Sections}}<h2>{{.Title}}</h2>
+{{if .Entries}}<table>
+<tr><th>#</th><th>Exercise</th><th>Completed</th><th>Attempts</th><th>Solution</th></tr>
+{{range .Entries}}<tr><td>{{.N}}</td><td>{{.Title}}</td><td>{{.Date}}</td><td>{{.Attempts}}</td><td>{{if .Pattern}}<code>{{.Pattern}}</code>{{else}}—{{end}}</td></tr>
+{{end}}</table>
+{{else}}<p>None yet.</p>
+{{end}}{{end}}`))
+
+// HTML renders t as a self-contained HTML page, signed with key unless it
+// is nil.
+func HTML(t Transcript, key ed25519.PrivateKey) []byte {
+	type row struct {
+		N                              int
+		Title, Date, Attempts, Pattern string
+	}
+	type section struct {
+		Title   string
+		Entries []row
+	}
+	rows := func(entries []Entry) []row {
+		var r []row
+		for i, e := range entries {
+			r = append(r, row{i + 1, e.Title, e.date(), e.attempts(), e.Pattern})
+		}
+		return r
+	}
+
+	var b bytes.Buffer
+	err := htmlTemplate.Execute(&b, map[string]any{
+		"Profile":   t.Profile,
+		"Status":    t.status(),
+		"Complete":  t.Complete(),
+		"Generated": t.GeneratedAt.Local().Format("2006-01-02 15:04 MST"),
+		"Sections": []section{
+			{"Lessons", rows(t.Lessons)},
+			{"Practice Problems", rows(t.Practices)},
+		},
+	})
+	if err != nil {
+		// The template is fixed and its data always fits it.
+		panic(err)
+	}
+
+	return seal(b.String(), key, htmlFooter, "<footer>Unsigned copy for your own records.</footer>\n</body>\n</html>\n")
+}
+
+// htmlFooter is the signature line of an HTML transcript and the end of
+// the document.
+func htmlFooter(sig string) string {
+	return "<footer><code>" + Marker + sig + "</code><br>Check with: learn-regex transcript verify -pub &lt;key&gt; &lt;file&gt;</footer>\n</body>\n</html>\n"
+}
+
+// Verify checks that a transcript in either format was signed with the
+// private key of pub, and is exactly as it was signed.
+func Verify(data []byte, pub ed25519.PublicKey) error {
+	i := bytes.LastIndex(data, []byte(Marker))
+	if i < 0 {
+		return ErrUnsigned
+	}
+
+	// The signature covers everything before the line it is on, and from
+	// that line on the transcript must be exactly what seal wrote.
+	lineStart := bytes.LastIndexByte(data[:i], '\n') + 1
+	start := i + len(Marker)
+	end := start + hex.EncodedLen(ed25519.SignatureSize)
+	if end > len(data) {
+		return ErrTampered
+	}
+	sig := string(data[start:end])
+	footer := string(data[lineStart:])
+	if footer != markdownFooter(sig) && footer != htmlFooter(sig) {
+		return ErrTampered
+	}
+	raw, err := hex.DecodeString(sig)
+	if err != nil || !ed25519.Verify(pub, data[:lineStart], raw) {
+		return ErrTampered
+	}
+	return nil
+}
+
+// GenerateKey returns a new signing key and its public key, both PEM
+// encoded.
+func GenerateKey() (private, public []byte, err error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return nil, nil, err
+	}
+	private = pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if der, err = x509.MarshalPKIXPublicKey(pub); err != nil {
+		return nil, nil, err
+	}
+	public = pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	return private, public, nil
+}
+
+// ParsePrivateKey reads a PEM signing key, as written by GenerateKey or
+// openssl genpkey -algorithm ed25519.
+func ParsePrivateKey(data []byte) (ed25519.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("not a PEM signing key")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	priv, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("signing key is %T, not Ed25519", key)
+	}
+	return priv, nil
+}
+
+// ParsePublicKey reads a PEM public key, as written by GenerateKey or
+// openssl pkey -pubout.
+func ParsePublicKey(data []byte) (ed25519.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("not a PEM public key")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key is %T, not Ed25519", key)
+	}
+	return pub, nil
+}
@@ -0,0 +1,142 @@
+package transcript
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+)
+
+func sample() Transcript {
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	return Transcript{
+		Profile:      "default",
+		GeneratedAt:  at,
+		TotalLessons: 2,
+		Lessons: []Entry{
+			{ExerciseID: "lesson/basics", Title: "Basic Patterns", CompletedAt: at, Attempts: 1, Pattern: "cat"},
+		},
+		Practices: []Entry{
+			{ExerciseID: "practice/pipes", Title: "Pipes", CompletedAt: at, Attempts: 3, Pattern: "a|b"},
+		},
+	}
+}
+
+func newKey(t *testing.T) (ed25519.PublicKey, ed25519.PrivateKey) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pub, priv
+}
+
+// resign replaces the signature line of b with one made from the edited
+// body, as someone without the signing key would.
+func resign(b []byte, footer func(string) string, key ed25519.PrivateKey) []byte {
+	i := bytes.LastIndex(b, []byte(Marker))
+	lineStart := bytes.LastIndexByte(b[:i], '\n') + 1
+	body := bytes.Replace(b[:lineStart], []byte("Basic Patterns"), []byte("Advanced Patterns"), 1)
+	var sig string
+	if key == nil {
+		// A hash of the body the length of a signature.
+		sum := sha512.Sum512(body)
+		sig = hex.EncodeToString(sum[:])
+	} else {
+		sig = hex.EncodeToString(ed25519.Sign(key, body))
+	}
+	return append(body, footer(sig)...)
+}
+
+func TestVerify(t *testing.T) {
+	pub, priv := newKey(t)
+	_, otherKey := newKey(t)
+
+	formats := []struct {
+		name   string
+		render func(Transcript, ed25519.PrivateKey) []byte
+		footer func(string) string
+	}{
+		{"markdown", Markdown, markdownFooter},
+		{"html", HTML, htmlFooter},
+	}
+	for _, f := range formats {
+		edits := []struct {
+			name string
+			edit func([]byte) []byte
+			want error
+		}{
+			{"untouched", func(b []byte) []byte { return b }, nil},
+			{"body edited", func(b []byte) []byte {
+				return bytes.Replace(b, []byte("Basic Patterns"), []byte("Advanced Patterns"), 1)
+			}, ErrTampered},
+			{"body edited and hash recomputed", func(b []byte) []byte {
+				return resign(b, f.footer, nil)
+			}, ErrTampered},
+			{"body edited and signed with another key", func(b []byte) []byte {
+				return resign(b, f.footer, otherKey)
+			}, ErrTampered},
+			{"appended after footer", func(b []byte) []byte {
+				return append(b, "\nI also finished every practice problem.\n"...)
+			}, ErrTampered},
+			{"trailing newline added", func(b []byte) []byte {
+				return append(b, '\n')
+			}, ErrTampered},
+			{"footer cut short", func(b []byte) []byte {
+				return b[:len(b)-2]
+			}, ErrTampered},
+			{"signature changed", func(b []byte) []byte {
+				i := bytes.LastIndex(b, []byte(Marker)) + len(Marker)
+				b = bytes.Clone(b)
+				b[i] ^= 1
+				return b
+			}, ErrTampered},
+		}
+		for _, e := range edits {
+			t.Run(f.name+"/"+e.name, func(t *testing.T) {
+				err := Verify(e.edit(f.render(sample(), priv)), pub)
+				if !errors.Is(err, e.want) {
+					t.Errorf("Verify = %v, want %v", err, e.want)
+				}
+			})
+		}
+
+		t.Run(f.name+"/unsigned", func(t *testing.T) {
+			if err := Verify(f.render(sample(), nil), pub); !errors.Is(err, ErrUnsigned) {
+				t.Errorf("Verify = %v, want %v", err, ErrUnsigned)
+			}
+		})
+	}
+}
+
+func TestVerifyNoMarker(t *testing.T) {
+	pub, _ := newKey(t)
+	if err := Verify([]byte("# Not a transcript\n"), pub); !errors.Is(err, ErrUnsigned) {
+		t.Errorf("Verify = %v, want %v", err, ErrUnsigned)
+	}
+}
+
+func TestKeys(t *testing.T) {
+	private, public, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, err := ParsePrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := ParsePublicKey(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify(Markdown(sample(), priv), pub); err != nil {
+		t.Errorf("Verify with the generated keys = %v", err)
+	}
+	if _, err := ParsePrivateKey(public); err == nil {
+		t.Error("ParsePrivateKey accepted a public key")
+	}
+}
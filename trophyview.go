@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/ghousemohamed/regex-in-the-terminal/achievements"
+	"github.com/ghousemohamed/regex-in-the-terminal/models"
+)
+
+// openTrophies shows the trophy screen with the badges stored for the
+// profile.
+func (m model) openTrophies() model {
+	progress, err := m.store.LoadProgress()
+	if err != nil {
+		m.err = err
+	}
+	for id, at := range progress.Badges {
+		m.badges[id] = at
+	}
+	m.parCount = achievements.ParCount(achievements.State{Practices: m.practices, Solved: progress.Solved})
+	m.state = models.Trophies
+	return m
+}
+
+// badgeShelf is a one-line row of the icons of every badge earned.
+func (m model) badgeShelf() string {
+	var icons []string
+	for _, b := range achievements.Badges {
+		if _, ok := m.badges[b.ID]; ok {
+			icons = append(icons, b.Icon)
+		}
+	}
+	return strings.Join(icons, " ")
+}
+
+func (m model) trophiesView(header string, totalWidth int) string {
+	var body strings.Builder
+	body.WriteString(gradientText("Trophies") + "\n\n")
+	body.WriteString(fmt.Sprintf("%d of %d badges earned\n\n", len(m.badges), len(achievements.Badges)))
+
+	for _, b := range achievements.Badges {
+		at, ok := m.badges[b.ID]
+		if !ok {
+			body.WriteString(lockedStyle.Render(fmt.Sprintf("   %-20s %s", b.Name, b.Description)))
+			if b.ID == "golf-par" {
+				body.WriteString(lockedStyle.Render(fmt.Sprintf(" (%d/%d)", m.parCount, achievements.ParGoal)))
+			}
+			body.WriteString("\n")
+			continue
+		}
+		body.WriteString(fmt.Sprintf("%s %s %s %s\n",
+			b.Icon,
+			completedStyle.Bold(true).Render(fmt.Sprintf("%-20s", b.Name)),
+			b.Description,
+			incompletedStyle.Render("· "+at.Local().Format("Jan 2, 2006"))))
+	}
+
+	body.WriteString("\n")
+	if m.err != nil {
+		body.WriteString(errorStyle.Render(m.err.Error()) + "\n\n")
+	}
+	body.WriteString(incompletedStyle.
+		Render(fmt.Sprintf("Press %s to go to main screen", keyName(keys.Back))))
+
+	return lipgloss.JoinVertical(lipgloss.Center,
+		header,
+		lipgloss.NewStyle().
+			Width(totalWidth-4).
+			Padding(1).
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(borderColor).
+			Render(body.String()),
+	)
+}